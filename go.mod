@@ -2,10 +2,13 @@ module github.com/ula/vgs-client
 
 go 1.19
 
-require github.com/stretchr/testify v1.8.2
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,168 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type AliasFormat string
+
+const (
+	AliasFormatUUID                         AliasFormat = "UUID"
+	AliasFormatRawUUID                      AliasFormat = "RAW_UUID"
+	AliasFormatFPESixTFour                  AliasFormat = "FPE_SIX_T_FOUR"
+	AliasFormatFPETFour                     AliasFormat = "FPE_T_FOUR"
+	AliasFormatPFPT                         AliasFormat = "PFPT"
+	AliasFormatNumLengthPreserving          AliasFormat = "NUM_LENGTH_PRESERVING"
+	AliasFormatAlphanumericLengthPreserving AliasFormat = "ALPHANUMERIC_LENGTH_PRESERVING"
+	AliasFormatFPEAccNumTFour               AliasFormat = "FPE_ACC_NUM_T_FOUR"
+	AliasFormatFPEAlphanumericAccNumTFour   AliasFormat = "FPE_ALPHANUMERIC_ACC_NUM_T_FOUR"
+	AliasFormatFPESSNTFour                  AliasFormat = "FPE_SSN_T_FOUR"
+	AliasFormatGenericTFour                 AliasFormat = "GENERIC_T_FOUR"
+)
+
+type AliasStorage string
+
+const (
+	AliasStoragePersistent AliasStorage = "PERSISTENT"
+	// Volatile aliases are kept for a limited time only, e.g. for CVCs.
+	AliasStorageVolatile AliasStorage = "VOLATILE"
+)
+
+type RedactRequest struct {
+	Value       string       `json:"value"`
+	Classifiers []string     `json:"classifiers,omitempty"`
+	Format      AliasFormat  `json:"format"`
+	Storage     AliasStorage `json:"storage,omitempty"`
+}
+
+type Alias struct {
+	Alias  string      `json:"alias"`
+	Format AliasFormat `json:"format"`
+}
+
+type AliasRecord struct {
+	Value       string       `json:"value,omitempty"`
+	Classifiers []string     `json:"classifiers,omitempty"`
+	Aliases     []Alias      `json:"aliases,omitempty"`
+	CreatedAt   time.Time    `json:"created_at,omitempty"`
+	Storage     AliasStorage `json:"storage,omitempty"`
+}
+
+type AliasRecords struct {
+	Data []AliasRecord `json:"data"`
+}
+
+// RevealedAliases maps every revealed alias to its record.
+type RevealedAliases struct {
+	Data map[string]AliasRecord `json:"data"`
+}
+
+type redactAliasesRequest struct {
+	Data []RedactRequest `json:"data"`
+}
+
+type updateAliasRequest struct {
+	Data struct {
+		Classifiers []string `json:"classifiers"`
+	} `json:"data"`
+}
+
+func aliasUri(alias string) string {
+	return fmt.Sprintf("/aliases/%s", url.PathEscape(alias))
+}
+
+// vaultApi sends a call to the vault API host instead of the payment url.
+func (c *Client) vaultApi(options []RequestOption) ([]RequestOption, error) {
+	baseUrl, err := c.Options.GetVaultApiUrl()
+	if err != nil {
+		return nil, err
+	}
+	return append([]RequestOption{WithBaseURL(baseUrl)}, options...), nil
+}
+
+// RedactAliases stores the values in the vault and returns their aliases, in
+// the order of values.
+func (c *Client) RedactAliases(values []RedactRequest, options ...RequestOption) (*AliasRecords, error) {
+	return c.RedactAliasesContext(c.Ctx, values, options...)
+}
+
+func (c *Client) RedactAliasesContext(ctx context.Context, values []RedactRequest, options ...RequestOption) (*AliasRecords, error) {
+	options, err := c.vaultApi(options)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AliasRecords{}
+	_, err = c.PostContext(ctx, "/aliases", &redactAliasesRequest{Data: values}, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) RevealAliases(aliases []string, options ...RequestOption) (*RevealedAliases, error) {
+	return c.RevealAliasesContext(c.Ctx, aliases, options...)
+}
+
+func (c *Client) RevealAliasesContext(ctx context.Context, aliases []string, options ...RequestOption) (*RevealedAliases, error) {
+	options, err := c.vaultApi(options)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, WithQuery(url.Values{"aliases": []string{strings.Join(aliases, ",")}}))
+	resp := &RevealedAliases{}
+	_, err = c.GetContext(ctx, "/aliases", resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) RevealAlias(alias string, options ...RequestOption) (*AliasRecords, error) {
+	return c.RevealAliasContext(c.Ctx, alias, options...)
+}
+
+func (c *Client) RevealAliasContext(ctx context.Context, alias string, options ...RequestOption) (*AliasRecords, error) {
+	options, err := c.vaultApi(options)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AliasRecords{}
+	_, err = c.GetContext(ctx, aliasUri(alias), resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UpdateAliasClassifiers replaces the classifiers of the aliased value.
+func (c *Client) UpdateAliasClassifiers(alias string, classifiers []string, options ...RequestOption) error {
+	return c.UpdateAliasClassifiersContext(c.Ctx, alias, classifiers, options...)
+}
+
+func (c *Client) UpdateAliasClassifiersContext(ctx context.Context, alias string, classifiers []string, options ...RequestOption) error {
+	options, err := c.vaultApi(options)
+	if err != nil {
+		return err
+	}
+	body := &updateAliasRequest{}
+	body.Data.Classifiers = classifiers
+	_, err = c.PutContext(ctx, aliasUri(alias), body, nil, options...)
+	return err
+}
+
+func (c *Client) DeleteAlias(alias string, options ...RequestOption) error {
+	return c.DeleteAliasContext(c.Ctx, alias, options...)
+}
+
+func (c *Client) DeleteAliasContext(ctx context.Context, alias string, options ...RequestOption) error {
+	options, err := c.vaultApi(options)
+	if err != nil {
+		return err
+	}
+	_, err = c.DeleteContext(ctx, aliasUri(alias), nil, options...)
+	return err
+}
@@ -0,0 +1,75 @@
+package vgs
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactAliases(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "api.sandbox.verygoodvault.com", r.URL.Host)
+		assert.Equal(t, "/aliases", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"data": [{"value": "4111111111111111", "classifiers": ["bank-account"], "format": "FPE_SIX_T_FOUR", "storage": "VOLATILE"}]}`, string(body))
+		w.Write([]byte(`{"data": [{"value": "4111111111111111", "classifiers": ["bank-account"], "aliases": [{"alias": "4111115Qxwpl1111", "format": "FPE_SIX_T_FOUR"}], "storage": "VOLATILE"}]}`))
+	})
+	records, err := c.RedactAliases([]RedactRequest{{
+		Value:       "4111111111111111",
+		Classifiers: []string{"bank-account"},
+		Format:      AliasFormatFPESixTFour,
+		Storage:     AliasStorageVolatile,
+	}})
+	assert.Nil(t, err)
+	assert.Len(t, records.Data, 1)
+	assert.Equal(t, "4111115Qxwpl1111", records.Data[0].Aliases[0].Alias)
+	assert.Equal(t, AliasStorageVolatile, records.Data[0].Storage)
+}
+
+func TestRevealAliases(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "api.sandbox.verygoodvault.com", r.URL.Host)
+		assert.Equal(t, "tok_1,tok_2", r.URL.Query().Get("aliases"))
+		w.Write([]byte(`{"data": {"tok_1": {"value": "one"}, "tok_2": {"value": "two"}}}`))
+	})
+	revealed, err := c.RevealAliases([]string{"tok_1", "tok_2"})
+	assert.Nil(t, err)
+	assert.Equal(t, "one", revealed.Data["tok_1"].Value)
+	assert.Equal(t, "two", revealed.Data["tok_2"].Value)
+}
+
+func TestRevealAlias(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/aliases/tok_1", http.StatusOK, `{"data": [{"value": "one", "aliases": [{"alias": "tok_1", "format": "UUID"}]}]}`))
+	records, err := c.RevealAlias("tok_1")
+	assert.Nil(t, err)
+	assert.Equal(t, "one", records.Data[0].Value)
+	assert.Equal(t, AliasFormatUUID, records.Data[0].Aliases[0].Format)
+}
+
+func TestUpdateAliasClassifiers(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/aliases/tok_1", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"data": {"classifiers": ["pii"]}}`, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	assert.Nil(t, c.UpdateAliasClassifiers("tok_1", []string{"pii"}))
+}
+
+func TestDeleteAlias(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodDelete, "/aliases/tok_1", http.StatusNoContent, ""))
+	assert.Nil(t, c.DeleteAlias("tok_1"))
+
+	c = NewMockClientWithHandler(newMockHandler(http.StatusNotFound, `{"errors": [{"code": "not_found", "detail": "dummy error"}]}`, nil))
+	assert.True(t, IsNotFound(c.DeleteAlias("tok_1")))
+}
@@ -1,26 +1,51 @@
 package vgs
 
 import (
+	"context"
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 var AuthEndpoint = "https://auth.verygoodsecurity.com/auth/realms/vgs/protocol/openid-connect/token"
 
+// DefaultRefreshWindow is how long before expiry a token is refreshed.
+const DefaultRefreshWindow = 30 * time.Second
+
+// backgroundRefreshTimeout bounds refreshes that no caller waits for.
+const backgroundRefreshTimeout = 30 * time.Second
+
+// Failed background refreshes are retried after a jittered exponential
+// backoff, the cached token is used meanwhile.
+const (
+	refreshBackoffBase = time.Second
+	refreshBackoffCap  = time.Minute
+)
+
 type Authenticator interface {
 	Authenticate() (*OAuthToken, error)
 	SetAuthentication(r *http.Request) error
 }
 
+// TokenInvalidator is implemented by authenticators that can drop a token
+// the API rejected, so the next SetAuthentication fetches a new one.
+type TokenInvalidator interface {
+	InvalidateToken(accessToken string)
+}
+
 type OAuthToken struct {
 	AccessToken      string `json:"access_token,omitempty"`
 	ExpiresIn        int    `json:"expires_in,omitempty"`
 	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
 	TokenType        string `json:"token_type,omitempty"`
 	NotBeforePolicy  int    `json:"not-before-policy,omitempty"`
 	Scope            string `json:"scope,omitempty"`
@@ -32,20 +57,78 @@ func (o *OAuthToken) IsValid() bool {
 		return false
 	}
 	now := time.Now()
-	return now.Before(o.CreatedAt.Add(time.Second * time.Duration(o.ExpiresIn)))
+	return now.Before(o.ExpiresAt())
+}
+
+func (o *OAuthToken) ExpiresAt() time.Time {
+	return o.CreatedAt.Add(time.Second * time.Duration(o.ExpiresIn))
+}
+
+// ExpiresWithin reports whether the token is not valid anymore after d.
+func (o *OAuthToken) ExpiresWithin(d time.Duration) bool {
+	if !o.IsValid() {
+		return true
+	}
+	return !time.Now().Add(d).Before(o.ExpiresAt())
+}
+
+// CanRefresh reports whether the refresh token can still be exchanged.
+func (o *OAuthToken) CanRefresh() bool {
+	if o == nil || o.RefreshToken == "" {
+		return false
+	}
+	if o.RefreshExpiresIn == 0 {
+		return true
+	}
+	return time.Now().Before(o.CreatedAt.Add(time.Second * time.Duration(o.RefreshExpiresIn)))
 }
 
 type OauthConfig struct {
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
 	GrantType    string `json:"grant_type"`
+	// Defaults to ClientSecretPost.
+	AuthMethod AuthMethod `json:"-"`
+	// Signs client assertions for PrivateKeyJWT.
+	Signer crypto.Signer `json:"-"`
+	KeyID  string        `json:"-"`
 }
 
+// OAuthAuthenticator is safe for concurrent use, concurrent calls without a
+// valid token share a single token fetch.
 type OAuthAuthenticator struct {
 	OAuthURL   string
 	Config     *OauthConfig
 	HTTPClient HTTPClient
-	Token      *OAuthToken
+	// Deprecated: read with CurrentToken when the authenticator is shared
+	// between goroutines.
+	Token *OAuthToken
+	// Tokens expiring within this window are refreshed before they are used.
+	RefreshWindow time.Duration
+	// Refresh tokens within RefreshWindow in the background and keep using
+	// the current token meanwhile, instead of blocking the caller. Failed
+	// refreshes are retried with backoff until the token expires.
+	BackgroundRefresh bool
+	// Shares tokens with other authenticators using the same credentials.
+	Store TokenStore
+	// Observe token requests.
+	Hooks []Hooks
+
+	mu       sync.Mutex
+	fetching *tokenFetch
+	// failed background refreshes in a row, the last error and when to try
+	// again
+	refreshFailures  int
+	refreshErr       error
+	refreshFailedAt  time.Time
+	nextRefreshAfter time.Time
+}
+
+// tokenFetch is a token request in flight that concurrent callers wait for.
+type tokenFetch struct {
+	done  chan struct{}
+	token *OAuthToken
+	err   error
 }
 
 func NewOAuthAuthenticator(clientId, clientSecret string) *OAuthAuthenticator {
@@ -56,12 +139,16 @@ func NewOAuthAuthenticator(clientId, clientSecret string) *OAuthAuthenticator {
 			ClientSecret: clientSecret,
 			GrantType:    "client_credentials",
 		},
-		HTTPClient: http.DefaultClient,
+		HTTPClient:        http.DefaultClient,
+		RefreshWindow:     DefaultRefreshWindow,
+		BackgroundRefresh: true,
+		Store:             NewMemoryTokenStore(),
 	}
 }
 
+// SetAuthentication fetches a missing token within the request context.
 func (o *OAuthAuthenticator) SetAuthentication(r *http.Request) error {
-	token, err := o.Authenticate()
+	token, err := o.AuthenticateContext(r.Context())
 	if err != nil {
 		return err
 	}
@@ -73,30 +160,223 @@ func (o *OAuthAuthenticator) SetAuthentication(r *http.Request) error {
 }
 
 func (o *OAuthAuthenticator) Authenticate() (*OAuthToken, error) {
-	if o.Token.IsValid() {
-		return o.Token, nil
+	return o.AuthenticateContext(context.Background())
+}
+
+func (o *OAuthAuthenticator) AuthenticateContext(ctx context.Context) (*OAuthToken, error) {
+	for {
+		o.mu.Lock()
+		current := o.Token
+		if !current.ExpiresWithin(o.RefreshWindow) {
+			o.mu.Unlock()
+			return current, nil
+		}
+		fetch := o.fetching
+		if current.IsValid() && o.BackgroundRefresh {
+			if fetch == nil && !time.Now().Before(o.nextRefreshAfter) {
+				fetch = &tokenFetch{done: make(chan struct{})}
+				o.fetching = fetch
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), backgroundRefreshTimeout)
+					defer cancel()
+					o.fetch(ctx, current, fetch)
+				}()
+			}
+			o.mu.Unlock()
+			return current, nil
+		}
+		if fetch == nil {
+			fetch = &tokenFetch{done: make(chan struct{})}
+			o.fetching = fetch
+			o.mu.Unlock()
+			return o.fetch(ctx, current, fetch)
+		}
+		o.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-fetch.done:
+		}
+		// the fetch was canceled by the context of the goroutine running it,
+		// try again with our own context
+		if errors.Is(fetch.err, context.Canceled) || errors.Is(fetch.err, context.DeadlineExceeded) {
+			continue
+		}
+		return fetch.token, fetch.err
+	}
+}
+
+// backOffRefresh delays the next background refresh after a failure. It is
+// called with o.mu held.
+func (o *OAuthAuthenticator) backOffRefresh(err error) {
+	o.refreshFailures++
+	o.refreshErr = err
+	o.refreshFailedAt = time.Now()
+	backoff := refreshBackoffCap
+	if o.refreshFailures < 7 {
+		backoff = refreshBackoffBase << (o.refreshFailures - 1)
 	}
-	token, err := o.FetchToken()
+	if backoff > refreshBackoffCap {
+		backoff = refreshBackoffCap
+	}
+	// wait between half and all of the backoff, so authenticators failing
+	// together spread their retries
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	o.nextRefreshAfter = o.refreshFailedAt.Add(backoff)
+}
+
+// fetch requests a token for all callers waiting on the fetch. A token saved
+// to the store by another authenticator is used when it is fresh, otherwise
+// the refresh token of current is used when possible.
+func (o *OAuthAuthenticator) fetch(ctx context.Context, current *OAuthToken, fetch *tokenFetch) (*OAuthToken, error) {
+	token, err := o.fetchShared(ctx, current)
+
+	o.mu.Lock()
+	if err == nil && token != nil {
+		// set token value to authenticator
+		o.Token = token
+		o.refreshFailures, o.refreshErr, o.nextRefreshAfter = 0, nil, time.Time{}
+	} else if err != nil && !errors.Is(err, context.Canceled) {
+		o.backOffRefresh(err)
+	}
+	o.fetching = nil
+	o.mu.Unlock()
+
+	fetch.token, fetch.err = token, err
+	close(fetch.done)
 	if err != nil {
 		return nil, err
 	}
-	// set token value to authenticator
-	o.Token = token
+	return token, nil
+}
 
+func (o *OAuthAuthenticator) fetchShared(ctx context.Context, current *OAuthToken) (*OAuthToken, error) {
+	if o.Store == nil {
+		return o.fetchNew(ctx, current)
+	}
+	key := o.storeKey()
+	if token := o.loadStored(ctx, key, current); token != nil {
+		return token, nil
+	}
+	if locker, ok := o.Store.(TokenLocker); ok {
+		unlock, err := locker.Lock(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		// another process may have fetched a token while we waited
+		if token := o.loadStored(ctx, key, current); token != nil {
+			return token, nil
+		}
+	}
+	token, err := o.fetchNew(ctx, current)
+	if err != nil || token == nil {
+		return token, err
+	}
+	// a failed save only costs other processes a fetch of their own
+	_ = o.Store.Save(ctx, key, token)
 	return token, nil
 }
 
+// loadStored returns the stored token unless it is missing, about to expire
+// or the current token that is being replaced.
+func (o *OAuthAuthenticator) loadStored(ctx context.Context, key string, current *OAuthToken) *OAuthToken {
+	token, err := o.Store.Load(ctx, key)
+	if err != nil || token.ExpiresWithin(o.RefreshWindow) {
+		return nil
+	}
+	if current != nil && token.AccessToken == current.AccessToken {
+		return nil
+	}
+	return token
+}
+
+func (o *OAuthAuthenticator) fetchNew(ctx context.Context, current *OAuthToken) (*OAuthToken, error) {
+	var token *OAuthToken
+	var err error
+	if current.CanRefresh() {
+		token, err = o.RefreshTokenContext(ctx, current.RefreshToken)
+	}
+	if token == nil {
+		token, err = o.FetchTokenContext(ctx)
+	}
+	return token, err
+}
+
+// storeKey identifies the credentials a token was issued for.
+func (o *OAuthAuthenticator) storeKey() string {
+	return o.Config.ClientID + "@" + o.OAuthURL
+}
+
+// InvalidateToken expires the cached token if it is still accessToken. The
+// refresh token is kept for the next fetch.
+func (o *OAuthAuthenticator) InvalidateToken(accessToken string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.Token == nil || o.Token.AccessToken != accessToken {
+		return
+	}
+	expired := *o.Token
+	expired.ExpiresIn = 0
+	o.Token = &expired
+}
+
+// CurrentToken returns the cached token, which may be expired or nil.
+func (o *OAuthAuthenticator) CurrentToken() *OAuthToken {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.Token
+}
+
 func (o *OAuthAuthenticator) FetchToken() (*OAuthToken, error) {
+	return o.FetchTokenContext(context.Background())
+}
+
+func (o *OAuthAuthenticator) FetchTokenContext(ctx context.Context) (*OAuthToken, error) {
 	data := url.Values{}
 	data.Add("grant_type", o.Config.GrantType)
-	data.Add("client_id", o.Config.ClientID)
-	data.Add("client_secret", o.Config.ClientSecret)
+	return o.requestToken(ctx, data)
+}
+
+// RefreshTokenContext exchanges a refresh token for a new token.
+func (o *OAuthAuthenticator) RefreshTokenContext(ctx context.Context, refreshToken string) (*OAuthToken, error) {
+	data := url.Values{}
+	data.Add("grant_type", "refresh_token")
+	data.Add("refresh_token", refreshToken)
+	return o.requestToken(ctx, data)
+}
+
+// requestToken requests a token and reports it to Hooks.
+func (o *OAuthAuthenticator) requestToken(ctx context.Context, data url.Values) (*OAuthToken, error) {
+	start := time.Now()
+	token, err := o.postToken(ctx, data)
+	for _, hooks := range o.Hooks {
+		if hooks.OnTokenFetch != nil {
+			hooks.OnTokenFetch(ctx, TokenFetchEvent{
+				GrantType: data.Get("grant_type"),
+				Start:     start,
+				Duration:  time.Since(start),
+				Err:       err,
+			})
+		}
+	}
+	return token, err
+}
 
+func (o *OAuthAuthenticator) postToken(ctx context.Context, data url.Values) (*OAuthToken, error) {
+	header := http.Header{}
+	if err := o.setClientAuthentication(header, data); err != nil {
+		return nil, err
+	}
 	buf := strings.NewReader(data.Encode())
-	req, err := http.NewRequest(http.MethodPost, o.OAuthURL, buf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.OAuthURL, buf)
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 	resp, err := o.HTTPClient.Do(req)
@@ -115,7 +395,53 @@ func (o *OAuthAuthenticator) FetchToken() (*OAuthToken, error) {
 		return &token, nil
 	}
 
+	return nil, newAuthError(resp)
+}
+
+func newAuthError(resp *http.Response) *AuthError {
+	authError := &AuthError{
+		StatusCode:   resp.StatusCode,
+		VGSRequestId: resp.Header.Get(VGSRequestId),
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		authError.ErrorDescription = err.Error()
+		return authError
+	}
 	var vgsError VGSError
-	err = json.NewDecoder(resp.Body).Decode(&vgsError)
-	return nil, err
+	if err := json.Unmarshal(body, &vgsError); err != nil {
+		authError.RawBody = string(body)
+		authError.ErrorDescription = http.StatusText(resp.StatusCode)
+		return authError
+	}
+	authError.ErrorCode = vgsError.ErrorCode
+	authError.ErrorDescription = vgsError.ErrorDescription
+	if authError.ErrorDescription == "" && len(vgsError.Errors) > 0 {
+		authError.ErrorDescription = vgsError.Errors[0].Detail
+	}
+	return authError
+}
+
+// isExpiredTokenResponse reports whether the API rejected the access token
+// because it expired.
+func isExpiredTokenResponse(response *Response) bool {
+	if response.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	if strings.Contains(response.Header.Get("WWW-Authenticate"), "invalid_token") {
+		return true
+	}
+	var vgsError VGSError
+	if json.Unmarshal(response.RawBody, &vgsError) != nil {
+		return false
+	}
+	if vgsError.ErrorCode == "invalid_token" || strings.Contains(strings.ToLower(vgsError.ErrorDescription), "expired") {
+		return true
+	}
+	for _, detail := range vgsError.Errors {
+		if strings.Contains(strings.ToLower(detail.Code+" "+detail.Detail), "expired") {
+			return true
+		}
+	}
+	return false
 }
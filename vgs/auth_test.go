@@ -0,0 +1,329 @@
+package vgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTokenServer(t *testing.T, handler http.HandlerFunc) *OAuthAuthenticator {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	authenticator := NewOAuthAuthenticator("test-client", "test-secret")
+	authenticator.OAuthURL = server.URL
+	return authenticator
+}
+
+func TestOAuthAuthenticatorFetchToken(t *testing.T) {
+	t.Parallel()
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "test-client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "test-secret", r.PostForm.Get("client_secret"))
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+	assert.True(t, token.IsValid())
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	assert.Nil(t, authenticator.SetAuthentication(req))
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+}
+
+func TestOAuthAuthenticatorContext(t *testing.T) {
+	t.Parallel()
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("token must not be fetched with a canceled context")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com", nil)
+	err := authenticator.SetAuthentication(req)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOAuthAuthenticatorSingleFetch(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+			assert.Nil(t, authenticator.SetAuthentication(req))
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	assert.Equal(t, "token", authenticator.CurrentToken().AccessToken)
+}
+
+func TestOAuthAuthenticatorCanceledLeader(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			close(started)
+			<-release
+			return
+		}
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderDone := make(chan error)
+	go func() {
+		_, err := authenticator.AuthenticateContext(ctx)
+		leaderDone <- err
+	}()
+	<-started
+
+	followerDone := make(chan *OAuthToken)
+	go func() {
+		token, err := authenticator.AuthenticateContext(context.Background())
+		assert.Nil(t, err)
+		followerDone <- token
+	}()
+	cancel()
+	assert.ErrorIs(t, <-leaderDone, context.Canceled)
+	assert.Equal(t, "token", (<-followerDone).AccessToken)
+	close(release)
+}
+
+func TestOAuthTokenExpiry(t *testing.T) {
+	t.Parallel()
+	token := &OAuthToken{AccessToken: "token", ExpiresIn: 60, CreatedAt: time.Now()}
+	assert.True(t, token.IsValid())
+	assert.False(t, token.ExpiresWithin(30*time.Second))
+	assert.True(t, token.ExpiresWithin(90*time.Second))
+	assert.False(t, token.CanRefresh())
+
+	token.RefreshToken = "refresh"
+	token.RefreshExpiresIn = 120
+	assert.True(t, token.CanRefresh())
+	token.CreatedAt = time.Now().Add(-time.Hour)
+	assert.False(t, token.IsValid())
+	assert.True(t, token.ExpiresWithin(0))
+	assert.False(t, token.CanRefresh())
+}
+
+func TestOAuthAuthenticatorRefreshWindow(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 300}`, atomic.AddInt32(&fetches, 1))
+	})
+	authenticator.BackgroundRefresh = false
+	authenticator.Token = &OAuthToken{AccessToken: "old", ExpiresIn: 10, CreatedAt: time.Now()}
+
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+
+	token, err = authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+}
+
+func TestOAuthAuthenticatorBackgroundRefresh(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"access_token": "new", "expires_in": 300}`))
+	})
+	authenticator.Token = &OAuthToken{AccessToken: "old", ExpiresIn: 10, CreatedAt: time.Now()}
+
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "old", token.AccessToken)
+	close(release)
+	assert.Eventually(t, func() bool {
+		return authenticator.CurrentToken().AccessToken == "new"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestOAuthAuthenticatorBackgroundRefreshBackoff(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	authenticator.Token = &OAuthToken{AccessToken: "old", ExpiresIn: 10, CreatedAt: time.Now()}
+
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "old", token.AccessToken)
+	assert.Eventually(t, func() bool {
+		authenticator.mu.Lock()
+		defer authenticator.mu.Unlock()
+		return authenticator.fetching == nil && authenticator.refreshErr != nil
+	}, time.Second, 5*time.Millisecond)
+
+	for i := 0; i < 50; i++ {
+		token, err := authenticator.Authenticate()
+		assert.Nil(t, err)
+		assert.Equal(t, "old", token.AccessToken)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	authenticator.mu.Lock()
+	assert.Equal(t, 1, authenticator.refreshFailures)
+	assert.True(t, authenticator.nextRefreshAfter.After(authenticator.refreshFailedAt))
+	authenticator.nextRefreshAfter = time.Now()
+	authenticator.mu.Unlock()
+
+	_, err = authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		authenticator.mu.Lock()
+		defer authenticator.mu.Unlock()
+		return authenticator.refreshFailures == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestOAuthAuthenticatorRefreshToken(t *testing.T) {
+	t.Parallel()
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "refresh", r.PostForm.Get("refresh_token"))
+		w.Write([]byte(`{"access_token": "refreshed", "expires_in": 300, "refresh_token": "refresh-2"}`))
+	})
+	authenticator.Token = &OAuthToken{AccessToken: "old", RefreshToken: "refresh", RefreshExpiresIn: 1800, CreatedAt: time.Now().Add(-time.Minute)}
+
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	assert.Equal(t, "refresh-2", token.RefreshToken)
+}
+
+func TestClientReplaysExpiredToken(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 300}`, atomic.AddInt32(&fetches, 1))
+	})
+	var calls int32
+	c, err := NewClient(&Options{
+		ClientID:      "test-client",
+		ClientSecret:  "test-secret",
+		VaultId:       "test-vault",
+		RouteId:       "test-route",
+		Authenticator: authenticator,
+		HTTPClient: &mockHTTPClient{func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"amount": 100, "source": "FI123", "auto_capture": true}`, string(body))
+			if r.Header.Get("Authorization") == "Bearer token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "invalid_token", "error_description": "Token expired"}`))
+				return
+			}
+			w.Write([]byte(`{"data": {"id": "XF123"}}`))
+		}},
+	})
+	assert.Nil(t, err)
+
+	transfer, err := c.CreateTransfer(&CreateTransferRequest{Amount: 100, Source: "FI123", AutoCapture: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "XF123", transfer.Data.ID)
+	assert.Equal(t, int32(2), calls)
+	assert.Equal(t, int32(2), fetches)
+}
+
+func TestClientDoesNotReplayOtherUnauthorized(t *testing.T) {
+	t.Parallel()
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+	var calls int32
+	c, err := NewClient(&Options{
+		ClientID:      "test-client",
+		ClientSecret:  "test-secret",
+		VaultId:       "test-vault",
+		RouteId:       "test-route",
+		Authenticator: authenticator,
+		HTTPClient: &mockHTTPClient{func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "unauthorized", "error_description": "dummy error"}`))
+		}},
+	})
+	assert.Nil(t, err)
+
+	_, err = c.GetGateways()
+	assert.ErrorContains(t, err, "dummy")
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestOAuthAuthenticatorErrors(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		status      int
+		body        string
+		kind        error
+		description string
+	}{
+		{http.StatusUnauthorized, `{"error": "invalid_client", "error_description": "Invalid client credentials"}`, ErrInvalidClient, "Invalid client credentials"},
+		{http.StatusBadRequest, `{"error": "unauthorized_client", "error_description": "Client not allowed"}`, ErrUnauthorizedClient, "Client not allowed"},
+		{http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "Token is not active"}`, ErrInvalidGrant, "Token is not active"},
+		{http.StatusTooManyRequests, `{"error": "too_many_requests"}`, ErrAuthRateLimited, ""},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, ErrAuthServer, "Bad Gateway"},
+		{http.StatusBadRequest, `{"error": "invalid_request"}`, ErrAuthentication, ""},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(VGSRequestId, "req-1")
+			w.WriteHeader(testCase.status)
+			w.Write([]byte(testCase.body))
+		})
+		token, err := authenticator.Authenticate()
+		assert.Nil(t, token)
+		assert.ErrorIs(t, err, ErrAuthentication)
+		assert.ErrorIs(t, err, testCase.kind)
+
+		var authError *AuthError
+		assert.ErrorAs(t, err, &authError)
+		assert.Equal(t, testCase.status, authError.StatusCode)
+		assert.Equal(t, "req-1", authError.VGSRequestId)
+		assert.Equal(t, testCase.description, authError.ErrorDescription)
+		assert.Contains(t, err.Error(), "req-1")
+
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		assert.ErrorIs(t, authenticator.SetAuthentication(req), testCase.kind)
+	}
+}
+
+func TestAuthErrorNotServerForBadCredentials(t *testing.T) {
+	t.Parallel()
+	err := error(&AuthError{StatusCode: http.StatusUnauthorized, ErrorCode: "invalid_client"})
+	assert.True(t, errors.Is(err, ErrInvalidClient))
+	assert.False(t, errors.Is(err, ErrAuthServer))
+	assert.False(t, errors.Is(err, ErrAuthRateLimited))
+}
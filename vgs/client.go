@@ -1,23 +1,18 @@
 package vgs
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
-	"strings"
-)
-
-type Environment string
-
-const (
-	Sandbox Environment = "sandbox"
-	Live    Environment = "live"
-	LiveEU1 Environment = "live-eu-1"
+	"sync"
+	"time"
 )
 
 type HTTPClient interface {
@@ -31,43 +26,108 @@ type Options struct {
 	RouteId      string
 	Environment  Environment
 
+	// Override the vault and payment urls of the environment.
 	VaultURL      *url.URL
 	PaymentURL    *url.URL
 	HTTPClient    HTTPClient
 	Authenticator Authenticator
+	// Retries transient failures, nil disables retries.
+	RetryPolicy *RetryPolicy
+	// Do not attach a generated Idempotency-Key to mutating calls.
+	DisableIdempotencyKeys bool
+	// Wrap every call, the first middleware is the outermost. The
+	// authentication with the current Authenticator is added after them.
+	Middlewares []Middleware
+	// Do not authenticate calls, e.g. when a middleware or the HTTPClient
+	// authenticates them instead.
+	DisableAuthentication bool
+	// Observe retries, and the token requests of the default authenticator.
+	Hooks []Hooks
+	// Receives the records of LogLevel and above, nil disables logging.
+	Logger   Logger
+	LogLevel LogLevel
+
+	// How the client authenticates to the token endpoint, defaults to
+	// ClientSecretPost.
+	AuthMethod AuthMethod
+	// Key signing PrivateKeyJWT assertions, or a PEM file to load it from.
+	PrivateKey     crypto.Signer
+	PrivateKeyFile string
+	KeyID          string
+	// Client certificate for TLSClientAuth. It is presented on API calls as
+	// well, so certificate bound tokens are accepted. It is only used to
+	// build the default HTTPClient, a custom HTTPClient has to present it
+	// itself, e.g. one built with NewMTLSHTTPClient.
+	TLSCertificate *tls.Certificate
+
+	// Credentials of the vault outbound proxy.
+	ProxyUsername string
+	ProxyPassword string
+	// PEM encoded VGS CA certificate the outbound proxy re-signs upstream
+	// connections with, or a file to read it from. Required with the proxy
+	// credentials.
+	ProxyCACert     []byte
+	ProxyCACertFile string
 }
 
 func (o *Options) GetVaultUrl() (*url.URL, error) {
+	if o.VaultURL != nil {
+		u := *o.VaultURL
+		return &u, nil
+	}
 	if o.VaultId == "" {
 		return nil, errors.New("VaultId is required")
 	}
-	u, err := url.Parse(fmt.Sprintf("https://%s.%s.verygoodproxy.com", o.VaultId, strings.ToLower(string(o.Environment))))
+	config, err := o.environment()
 	if err != nil {
 		return nil, err
 	}
-	return u, nil
+	return o.url(config, config.VaultHost)
 }
 
 func (o *Options) GetPaymentUrl() (*url.URL, error) {
+	if o.PaymentURL != nil {
+		u := *o.PaymentURL
+		return &u, nil
+	}
 	if o.VaultId == "" {
 		return nil, errors.New("VaultId is required")
 	}
 	if o.RouteId == "" {
 		return nil, errors.New("RouteId is required")
 	}
-	u, err := url.Parse(fmt.Sprintf("https://%s-%s.%s.verygoodproxy.com", o.VaultId, o.RouteId, strings.ToLower(string(o.Environment))))
+	config, err := o.environment()
 	if err != nil {
 		return nil, err
 	}
-	return u, nil
+	return o.url(config, config.PaymentHost)
 }
 
+// GetVaultApiUrl returns the url of the vault API, which manages aliases.
+func (o *Options) GetVaultApiUrl() (*url.URL, error) {
+	config, err := o.environment()
+	if err != nil {
+		return nil, err
+	}
+	return o.url(config, config.VaultApiHost)
+}
+
+// Client is safe for concurrent use by multiple goroutines.
 type Client struct {
 	Options *Options
 	Ctx     context.Context
 
-	LastRequest  *http.Request
+	// Deprecated: shared between concurrent calls, use WithResponse to get
+	// the response of a single call.
+	LastRequest *http.Request
+	// Deprecated: shared between concurrent calls, use WithResponse to get
+	// the response of a single call.
 	LastResponse *http.Response
+
+	mu sync.Mutex
+	// created by the client from the options, and so not shared with other
+	// clients
+	authenticator *OAuthAuthenticator
 }
 
 func NewClient(options *Options) (*Client, error) {
@@ -75,91 +135,202 @@ func NewClient(options *Options) (*Client, error) {
 }
 
 func NewClientWithContext(ctx context.Context, options *Options) (*Client, error) {
-	if options.ClientID == "" || options.ClientSecret == "" {
-		return nil, errors.New("client id and client secret is required")
-	}
-	if options.VaultId == "" {
-		return nil, errors.New("vault id is required")
-	}
-	if options.RouteId == "" {
-		return nil, errors.New("route id is required")
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
 	if options.HTTPClient == nil {
-		options.HTTPClient = http.DefaultClient
+		if options.TLSCertificate != nil {
+			options.HTTPClient = NewMTLSHTTPClient(*options.TLSCertificate)
+		} else {
+			options.HTTPClient = http.DefaultClient
+		}
 	}
 	if options.Environment == "" {
 		options.Environment = Sandbox
 	}
-	if options.Authenticator == nil {
-		options.Authenticator = NewOAuthAuthenticator(
-			options.ClientID, options.ClientSecret,
-		)
-	}
-
 	client := &Client{
 		Options: options,
 		Ctx:     ctx,
 	}
+	if options.Authenticator == nil {
+		authenticator, err := options.newAuthenticator()
+		if err != nil {
+			return nil, err
+		}
+		options.Authenticator = authenticator
+		client.authenticator = authenticator
+	}
 	return client, nil
 }
 
+// Validate reports every problem of the options at once, as a
+// *ValidationError.
+func (o *Options) Validate() error {
+	var problems []error
+	switch o.AuthMethod {
+	case "", ClientSecretPost, ClientSecretBasic:
+		if o.ClientID == "" || o.ClientSecret == "" {
+			problems = append(problems, errors.New("client id and client secret is required"))
+		}
+	case PrivateKeyJWT:
+		if o.ClientID == "" {
+			problems = append(problems, errors.New("client id is required"))
+		}
+		if o.PrivateKey == nil && o.PrivateKeyFile == "" {
+			problems = append(problems, errors.New("private key is required for private_key_jwt"))
+		}
+	case TLSClientAuth:
+		if o.ClientID == "" {
+			problems = append(problems, errors.New("client id is required"))
+		}
+		if o.TLSCertificate == nil && o.HTTPClient == nil {
+			problems = append(problems, errors.New("tls certificate is required for tls_client_auth"))
+		}
+	default:
+		problems = append(problems, fmt.Errorf("unsupported auth method: %s", o.AuthMethod))
+	}
+	if o.VaultId == "" {
+		problems = append(problems, errors.New("vault id is required"))
+	}
+	if o.RouteId == "" {
+		problems = append(problems, errors.New("route id is required"))
+	}
+	if _, err := o.environment(); err != nil {
+		problems = append(problems, err)
+	}
+	if (o.ProxyUsername == "") != (o.ProxyPassword == "") {
+		problems = append(problems, errors.New("proxy username and proxy password must be set together"))
+	}
+	if (o.ProxyUsername != "" || o.ProxyPassword != "") && len(o.ProxyCACert) == 0 && o.ProxyCACertFile == "" {
+		problems = append(problems, errProxyCACertRequired)
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// newAuthenticator returns the OAuth authenticator for the AuthMethod.
+func (o *Options) newAuthenticator() (*OAuthAuthenticator, error) {
+	var authenticator *OAuthAuthenticator
+	switch o.AuthMethod {
+	case PrivateKeyJWT:
+		signer := o.PrivateKey
+		if signer == nil {
+			key, err := LoadPrivateKey(o.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			signer = key
+		}
+		authenticator = NewPrivateKeyJWTAuthenticator(o.ClientID, signer, o.KeyID)
+	case TLSClientAuth:
+		authenticator = NewMTLSAuthenticator(o.ClientID, o.HTTPClient)
+	default:
+		authenticator = NewOAuthAuthenticator(o.ClientID, o.ClientSecret)
+		authenticator.Config.AuthMethod = o.AuthMethod
+	}
+	if o.TLSCertificate != nil {
+		// fetch tokens over the client certificate so they are bound to it
+		authenticator.HTTPClient = o.HTTPClient
+	}
+	authenticator.OAuthURL = o.authEndpoint()
+	authenticator.Hooks = append(authenticator.Hooks, o.Hooks...)
+	return authenticator, nil
+}
+
 func (c *Client) NewRequest(request *Request) (*http.Request, error) {
-	baseUrl, err := c.Options.GetPaymentUrl()
-	if err != nil {
-		log.Printf("Unable to parse base url: %s", err)
-		return nil, err
+	return c.NewRequestContext(context.Background(), request)
+}
+
+// NewRequestContext builds a request for Do. It has no Authorization header,
+// the authentication is added when it is sent, so requests sent with another
+// client have to be authenticated with Options.Authenticator.
+func (c *Client) NewRequestContext(ctx context.Context, request *Request) (*http.Request, error) {
+	baseUrl := request.BaseURL
+	if baseUrl == nil {
+		paymentUrl, err := c.Options.GetPaymentUrl()
+		if err != nil {
+			c.Options.log(ctx, LogLevelError, "unable to build base url", "error", err)
+			return nil, err
+		}
+		baseUrl = paymentUrl
 	}
 	fullUrl, err := baseUrl.Parse(request.Uri)
 	if err != nil {
-		log.Printf("Unable to parse base url: %s", request.Uri)
+		c.Options.log(ctx, LogLevelError, "unable to parse request uri", "uri", redactURI(request.Uri), "error", err)
 		return nil, err
 	}
-	var buf io.Reader
+	if len(request.Values) > 0 {
+		query := fullUrl.Query()
+		for key, values := range request.Values {
+			query[key] = append(query[key], values...)
+		}
+		fullUrl.RawQuery = query.Encode()
+	}
+	var body []byte
 	if request.hasBody() {
-		buf, _ = request.jsonBody()
+		buf, err := request.jsonBody()
+		if err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
 	}
-	req, err := http.NewRequest(request.Method, fullUrl.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, request.Method, fullUrl.String(), nil)
 	if err != nil {
-		log.Printf("Unable to create a new request: %s", err)
+		c.Options.log(ctx, LogLevelError, "unable to create request", "error", err)
 		return nil, err
 	}
+	if body != nil {
+		// every attempt of a retried request reads a fresh copy of the body
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+		req.ContentLength = int64(len(body))
+	}
 	// set request headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-
-	// set authentication headers
-	if err := c.Options.Authenticator.SetAuthentication(req); err != nil {
-		return nil, err
+	for key, values := range request.Headers {
+		req.Header[key] = values
 	}
 	return req, nil
 }
 
+// Do sends req through Options.Middlewares and decodes the response body
+// into v. It is bound by the context of req, or Client.Ctx for requests
+// without one, e.g. built with NewRequest.
 func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
-	req = req.WithContext(c.Ctx)
-	c.LastRequest = req
-	resp, err := c.Options.HTTPClient.Do(req)
+	ctx := req.Context()
+	if ctx == context.Background() && c.Ctx != nil {
+		ctx = c.Ctx
+	}
+	return c.do(ctx, nil, req, v)
+}
+
+func (c *Client) do(ctx context.Context, request *Request, req *http.Request, v interface{}) (*Response, error) {
+	if request != nil {
+		ctx = context.WithValue(ctx, requestKey{}, request)
+	}
+	req = req.WithContext(ctx)
+	response, err := chain(c.roundTrip, c.middlewares())(request, req)
 	if err != nil {
+		if response != nil {
+			return response, err
+		}
 		select {
-		case <-c.Ctx.Done():
-			return nil, c.Ctx.Err()
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		default:
 		}
 		return nil, err
 	}
-	c.LastResponse = resp
-
-	response := NewResponse(resp)
-	defer resp.Body.Close()
-
-	err = ValidateResponse(response)
-	if err != nil {
-		return response, err
-	}
 
 	switch v := v.(type) {
 	case nil:
 	case io.Writer:
-		_, err = io.Copy(v, response.Body)
+		_, err = v.Write(response.RawBody)
 	default:
 		if len(response.RawBody) > 0 {
 			if err = json.Unmarshal(response.RawBody, &v); err != nil {
@@ -171,31 +342,134 @@ func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
 	return response, err
 }
 
-func (c *Client) Get(uri string, v interface{}, options ...RequestOption) (*Response, error) {
-	req, err := c.NewRequest(&Request{
-		Method: http.MethodGet,
-		Uri:    uri,
-	})
+// roundTrip is the innermost Handler, it sends the request and reads the
+// response.
+func (c *Client) roundTrip(_ *Request, req *http.Request) (*Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	resp, err := c.send(ctx, req)
+	fields := []interface{}{"method", req.Method, "path", redactURL(req.URL).Path}
 	if err != nil {
+		c.Options.log(ctx, LogLevelError, "request failed", append(fields, "latency", time.Since(start), "error", err)...)
 		return nil, err
 	}
-	resp, err := c.Do(req, v)
+	c.mu.Lock()
+	c.LastResponse = resp
+	c.mu.Unlock()
+
+	response, err := ReadResponse(resp)
+	resp.Body.Close()
 	if err != nil {
+		c.Options.log(ctx, LogLevelError, "unable to read response body", append(fields, "status", resp.StatusCode, "error", err)...)
 		return nil, err
 	}
-	return resp, nil
+	fields = append(fields, "status", response.StatusCode, "latency", time.Since(start),
+		"vgs_request_id", response.VGSRequestId, "trace_id", response.TraceId)
+
+	if err := ValidateResponse(response); err != nil {
+		c.Options.log(ctx, LogLevelWarn, "request rejected", append(fields, "error", err)...)
+		return response, err
+	}
+	c.Options.log(ctx, LogLevelDebug, "request completed", fields...)
+	return response, nil
+}
+
+// send performs the request, retrying it according to Options.RetryPolicy.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	policy := c.Options.RetryPolicy
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		c.mu.Lock()
+		c.LastRequest = req
+		c.mu.Unlock()
+		resp, err := c.Options.HTTPClient.Do(req)
+		if ctx.Err() != nil || policy == nil || attempt >= policy.MaxAttempts || !policy.retryable(req, resp, err) {
+			return resp, err
+		}
+		wait, ok := policy.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		fields := []interface{}{"method", req.Method, "path", redactURL(req.URL).Path, "attempt", attempt, "delay", wait}
+		if resp != nil {
+			fields = append(fields, "status", resp.StatusCode)
+		} else {
+			fields = append(fields, "error", err)
+		}
+		c.Options.log(ctx, LogLevelWarn, "retrying request", fields...)
+		c.Options.onRetry(ctx, req, attempt, resp, err)
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) Get(uri string, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.GetContext(c.Ctx, uri, v, options...)
+}
+
+func (c *Client) GetContext(ctx context.Context, uri string, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.call(ctx, http.MethodGet, uri, nil, v, options...)
 }
 
 func (c *Client) Post(uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
-	req, err := c.NewRequest(&Request{
-		Method: http.MethodPost,
-		Uri:    uri,
-		Body:   payload,
-	})
+	return c.PostContext(c.Ctx, uri, payload, v, options...)
+}
+
+func (c *Client) PostContext(ctx context.Context, uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.call(ctx, http.MethodPost, uri, payload, v, options...)
+}
+
+func (c *Client) Put(uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.PutContext(c.Ctx, uri, payload, v, options...)
+}
+
+func (c *Client) PutContext(ctx context.Context, uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.call(ctx, http.MethodPut, uri, payload, v, options...)
+}
+
+func (c *Client) Patch(uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.PatchContext(c.Ctx, uri, payload, v, options...)
+}
+
+func (c *Client) PatchContext(ctx context.Context, uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.call(ctx, http.MethodPatch, uri, payload, v, options...)
+}
+
+func (c *Client) Delete(uri string, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.DeleteContext(c.Ctx, uri, v, options...)
+}
+
+func (c *Client) DeleteContext(ctx context.Context, uri string, v interface{}, options ...RequestOption) (*Response, error) {
+	return c.call(ctx, http.MethodDelete, uri, nil, v, options...)
+}
+
+func (c *Client) call(ctx context.Context, method, uri string, payload, v interface{}, options ...RequestOption) (*Response, error) {
+	request := NewJsonRequest(method, uri, payload, options...)
+	if request.isMutating() && !c.Options.DisableIdempotencyKeys && request.Headers.Get(IdempotencyKeyHeader) == "" {
+		// generated once per call, so retries of this call reuse the key
+		request.setHeader(IdempotencyKeyHeader, NewIdempotencyKey())
+	}
+	ctx, cancel := request.context(ctx)
+	defer cancel()
+	req, err := c.NewRequestContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.Do(req, v)
+	resp, err := c.do(ctx, request, req, v)
+	if request.response != nil {
+		*request.response = resp
+	}
 	if err != nil {
 		return nil, err
 	}
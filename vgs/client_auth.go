@@ -0,0 +1,206 @@
+package vgs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// AuthMethod is how the client authenticates to the token endpoint.
+type AuthMethod string
+
+const (
+	// ClientSecretPost sends the client secret in the form body.
+	ClientSecretPost AuthMethod = "client_secret_post"
+	// ClientSecretBasic sends the client secret in a Basic Authorization header.
+	ClientSecretBasic AuthMethod = "client_secret_basic"
+	// PrivateKeyJWT sends a client assertion signed with a private key.
+	PrivateKeyJWT AuthMethod = "private_key_jwt"
+	// TLSClientAuth authenticates with the client certificate of a mutual
+	// TLS connection, tokens are bound to that certificate.
+	TLSClientAuth AuthMethod = "tls_client_auth"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// assertionLifetime is how long a client assertion is accepted.
+const assertionLifetime = time.Minute
+
+func NewPrivateKeyJWTAuthenticator(clientId string, signer crypto.Signer, keyId string) *OAuthAuthenticator {
+	authenticator := NewOAuthAuthenticator(clientId, "")
+	authenticator.Config.AuthMethod = PrivateKeyJWT
+	authenticator.Config.Signer = signer
+	authenticator.Config.KeyID = keyId
+	return authenticator
+}
+
+// NewMTLSAuthenticator authenticates with a client certificate, httpClient
+// must present it, e.g. one built with NewMTLSHTTPClient.
+func NewMTLSAuthenticator(clientId string, httpClient HTTPClient) *OAuthAuthenticator {
+	authenticator := NewOAuthAuthenticator(clientId, "")
+	authenticator.Config.AuthMethod = TLSClientAuth
+	authenticator.HTTPClient = httpClient
+	return authenticator
+}
+
+// NewMTLSHTTPClient returns an http client presenting cert on every TLS
+// connection.
+func NewMTLSHTTPClient(cert tls.Certificate) *http.Client {
+	transport := cloneDefaultTransport()
+	transport.TLSClientConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return &http.Client{Transport: transport}
+}
+
+// cloneDefaultTransport clones http.DefaultTransport, unless it was replaced
+// with another RoundTripper.
+func cloneDefaultTransport() *http.Transport {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		return transport.Clone()
+	}
+	return &http.Transport{}
+}
+
+// LoadPrivateKey reads a PEM encoded RSA or EC private key in PKCS#1,
+// PKCS#8 or SEC 1 form.
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// setClientAuthentication adds the client credentials to the headers and
+// form of a token request.
+func (o *OAuthAuthenticator) setClientAuthentication(header http.Header, data url.Values) error {
+	data.Set("client_id", o.Config.ClientID)
+	switch o.Config.AuthMethod {
+	case "", ClientSecretPost:
+		data.Set("client_secret", o.Config.ClientSecret)
+	case ClientSecretBasic:
+		credentials := url.QueryEscape(o.Config.ClientID) + ":" + url.QueryEscape(o.Config.ClientSecret)
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	case PrivateKeyJWT:
+		assertion, err := o.clientAssertion()
+		if err != nil {
+			return err
+		}
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", assertion)
+	case TLSClientAuth:
+		// the client certificate of the connection authenticates the client
+	default:
+		return fmt.Errorf("unsupported auth method: %s", o.Config.AuthMethod)
+	}
+	return nil
+}
+
+// clientAssertion returns a signed JWT identifying the client to the token
+// endpoint, as defined in RFC 7523.
+func (o *OAuthAuthenticator) clientAssertion() (string, error) {
+	signer := o.Config.Signer
+	if signer == nil {
+		return "", errors.New("private key is required for private_key_jwt")
+	}
+	alg, err := signingAlgorithm(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if o.Config.KeyID != "" {
+		header["kid"] = o.Config.KeyID
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": o.Config.ClientID,
+		"sub": o.Config.ClientID,
+		"aud": o.OAuthURL,
+		"jti": NewIdempotencyKey(),
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	}
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJson, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJson) + "." + base64.RawURLEncoding.EncodeToString(claimsJson)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	if alg == "ES256" {
+		if signature, err = ecdsaRawSignature(signature); err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func signingAlgorithm(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return "ES256", nil
+		}
+	}
+	return "", fmt.Errorf("unsupported signing key type %T, RSA or P-256 EC keys are supported", key)
+}
+
+// ecdsaRawSignature converts an ASN.1 ECDSA signature into the fixed size
+// r || s form JWS requires.
+func ecdsaRawSignature(der []byte) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+	return raw, nil
+}
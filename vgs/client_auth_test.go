@@ -0,0 +1,245 @@
+package vgs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// verifyAssertion checks a client assertion the way a token endpoint does.
+func verifyAssertion(t *testing.T, assertion string, key crypto.PublicKey, audience string) {
+	parts := strings.Split(assertion, ".")
+	if !assert.Len(t, parts, 3) {
+		return
+	}
+	var header map[string]string
+	var claims map[string]interface{}
+	headerJson, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJson, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	assert.Nil(t, json.Unmarshal(headerJson, &header))
+	assert.Nil(t, json.Unmarshal(claimsJson, &claims))
+
+	assert.Equal(t, "kid-1", header["kid"])
+	assert.Equal(t, "test-client", claims["iss"])
+	assert.Equal(t, "test-client", claims["sub"])
+	assert.Equal(t, audience, claims["aud"])
+	assert.NotEmpty(t, claims["jti"])
+	assert.Greater(t, claims["exp"], float64(time.Now().Unix()))
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		assert.Equal(t, "RS256", header["alg"])
+		assert.Nil(t, rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature))
+	case *ecdsa.PublicKey:
+		assert.Equal(t, "ES256", header["alg"])
+		assert.Len(t, signature, 64)
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		assert.True(t, ecdsa.Verify(k, digest[:], r, s))
+	}
+}
+
+func TestPrivateKeyJWTAuthenticator(t *testing.T) {
+	t.Parallel()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	for _, signer := range []crypto.Signer{rsaKey, ecKey} {
+		signer := signer
+		var authenticator *OAuthAuthenticator
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Nil(t, r.ParseForm())
+			assert.Equal(t, "test-client", r.PostForm.Get("client_id"))
+			assert.Empty(t, r.PostForm.Get("client_secret"))
+			assert.Equal(t, clientAssertionType, r.PostForm.Get("client_assertion_type"))
+			verifyAssertion(t, r.PostForm.Get("client_assertion"), signer.Public(), authenticator.OAuthURL)
+			w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+		}))
+		authenticator = NewPrivateKeyJWTAuthenticator("test-client", signer, "kid-1")
+		authenticator.OAuthURL = server.URL
+
+		token, err := authenticator.Authenticate()
+		assert.Nil(t, err)
+		assert.Equal(t, "token", token.AccessToken)
+		server.Close()
+	}
+}
+
+func TestPrivateKeyJWTUnsupportedKey(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+	authenticator := NewPrivateKeyJWTAuthenticator("test-client", key, "")
+	_, err = authenticator.FetchToken()
+	assert.ErrorContains(t, err, "unsupported signing key")
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecDer, _ := x509.MarshalECPrivateKey(ecKey)
+	pkcs8Der, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+
+	testCases := []struct {
+		block *pem.Block
+		key   crypto.PublicKey
+	}{
+		{&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, rsaKey.Public()},
+		{&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDer}, ecKey.Public()},
+		{&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Der}, ecKey.Public()},
+	}
+	for i, testCase := range testCases {
+		path := filepath.Join(dir, string(rune('a'+i))+".pem")
+		assert.Nil(t, os.WriteFile(path, pem.EncodeToMemory(testCase.block), 0o600))
+		signer, err := LoadPrivateKey(path)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.key, signer.Public())
+	}
+
+	path := filepath.Join(dir, "empty.pem")
+	assert.Nil(t, os.WriteFile(path, []byte("dummy"), 0o600))
+	_, err := LoadPrivateKey(path)
+	assert.ErrorContains(t, err, "no PEM data")
+}
+
+func TestClientSecretBasic(t *testing.T) {
+	t.Parallel()
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "test-client", id)
+		assert.Equal(t, "test-secret", secret)
+		assert.Nil(t, r.ParseForm())
+		assert.Empty(t, r.PostForm.Get("client_secret"))
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+	authenticator.Config.AuthMethod = ClientSecretBasic
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+}
+
+func newTestCertificate(t *testing.T, commonName string) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, cert
+}
+
+func TestMTLSAuthenticator(t *testing.T) {
+	t.Parallel()
+	clientCert, _ := newTestCertificate(t, "test-client")
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Len(t, r.TLS.PeerCertificates, 1)
+		assert.Equal(t, "test-client", r.TLS.PeerCertificates[0].Subject.CommonName)
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "test-client", r.PostForm.Get("client_id"))
+		assert.Empty(t, r.PostForm.Get("client_secret"))
+		w.Write([]byte(`{"access_token": "bound-token", "expires_in": 300}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	c, err := NewClient(&Options{
+		ClientID:       "test-client",
+		VaultId:        "test-vault",
+		RouteId:        "test-route",
+		AuthMethod:     TLSClientAuth,
+		TLSCertificate: &clientCert,
+	})
+	assert.Nil(t, err)
+	httpClient := c.Options.HTTPClient.(*http.Client)
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs = roots
+
+	authenticator := c.Options.Authenticator.(*OAuthAuthenticator)
+	assert.Same(t, httpClient, authenticator.HTTPClient)
+	authenticator.OAuthURL = server.URL
+	token, err := authenticator.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "bound-token", token.AccessToken)
+}
+
+type wrappedTransport struct {
+	http.RoundTripper
+}
+
+func TestNewMTLSHTTPClientWrappedDefaultTransport(t *testing.T) {
+	// not parallel, http.DefaultTransport is replaced
+	defaultTransport := http.DefaultTransport
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+	http.DefaultTransport = wrappedTransport{defaultTransport}
+
+	cert, x509Cert := newTestCertificate(t, "test-client")
+	client := NewMTLSHTTPClient(cert)
+	assert.Len(t, client.Transport.(*http.Transport).TLSClientConfig.Certificates, 1)
+
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: x509Cert.Raw})
+	_, err := NewOutboundTransport(&Options{VaultId: "tntabc", ProxyUsername: "user", ProxyPassword: "pass", ProxyCACert: caPem})
+	assert.Nil(t, err)
+}
+
+func TestNewClientAuthMethods(t *testing.T) {
+	t.Parallel()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	cert, _ := newTestCertificate(t, "test-client")
+	testCases := []struct {
+		expectedError string
+		options       *Options
+	}{
+		{"private key is required", &Options{ClientID: "test", AuthMethod: PrivateKeyJWT, VaultId: "test", RouteId: "test"}},
+		{"", &Options{ClientID: "test", AuthMethod: PrivateKeyJWT, PrivateKey: key, VaultId: "test", RouteId: "test"}},
+		{"no such file", &Options{ClientID: "test", AuthMethod: PrivateKeyJWT, PrivateKeyFile: "/nonexistent.pem", VaultId: "test", RouteId: "test"}},
+		{"tls certificate is required", &Options{ClientID: "test", AuthMethod: TLSClientAuth, VaultId: "test", RouteId: "test"}},
+		{"", &Options{ClientID: "test", AuthMethod: TLSClientAuth, TLSCertificate: &cert, VaultId: "test", RouteId: "test"}},
+		{"client id and client secret", &Options{ClientID: "test", AuthMethod: ClientSecretBasic, VaultId: "test", RouteId: "test"}},
+		{"unsupported auth method", &Options{ClientID: "test", AuthMethod: "dummy", VaultId: "test", RouteId: "test"}},
+	}
+	for _, testCase := range testCases {
+		c, err := NewClient(testCase.options)
+		if testCase.expectedError != "" {
+			assert.ErrorContains(t, err, testCase.expectedError)
+			assert.Nil(t, c)
+		} else {
+			assert.Nil(t, err)
+			assert.NotNil(t, c)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package vgs
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -80,6 +83,15 @@ func newMockHandler(statusCode int, json string, headers map[string]string) http
 	}
 }
 
+func newExpectHandler(t *testing.T, method, path string, statusCode int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, method, r.Method)
+		assert.Equal(t, path, r.URL.Path)
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -189,3 +201,82 @@ func TestHTTPClientBadJSON(t *testing.T) {
 	assert.Nil(t, account)
 	assert.ErrorContains(t, err, "invalid character")
 }
+
+func TestClientVerbs(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		method string
+		call   func(c *Client) (*Response, error)
+	}{
+		{http.MethodGet, func(c *Client) (*Response, error) { return c.Get("/dummy", nil) }},
+		{http.MethodPost, func(c *Client) (*Response, error) { return c.Post("/dummy", map[string]string{}, nil) }},
+		{http.MethodPut, func(c *Client) (*Response, error) { return c.Put("/dummy", map[string]string{}, nil) }},
+		{http.MethodPatch, func(c *Client) (*Response, error) { return c.Patch("/dummy", map[string]string{}, nil) }},
+		{http.MethodDelete, func(c *Client) (*Response, error) { return c.Delete("/dummy", nil) }},
+	}
+	for _, testCase := range testCases {
+		c := NewMockClientWithHandler(newExpectHandler(t, testCase.method, "/dummy", http.StatusOK, ""))
+		resp, err := testCase.call(c)
+		assert.Nil(t, err)
+		assert.NotNil(t, resp)
+	}
+}
+
+func TestContextVariants(t *testing.T) {
+	t.Parallel()
+	c, err := NewMockClient(&badMockHTTPClient{})
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetGatewaysContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = c.CreateTransferContext(ctx, &CreateTransferRequest{})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// the client context is left untouched
+	_, err = c.GetGateways()
+	assert.NotErrorIs(t, err, context.Canceled)
+}
+
+func TestClientConcurrentCalls(t *testing.T) {
+	t.Parallel()
+	var requests int32
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		id := atomic.AddInt32(&requests, 1)
+		w.Header().Set(VGSRequestId, fmt.Sprintf("req-%d", id))
+		w.Write([]byte(`{"data": []}`))
+	})
+	c.Options.RetryPolicy = DefaultRetryPolicy()
+
+	var wg sync.WaitGroup
+	ids := make([]string, 50)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var resp *Response
+			_, err := c.GetGateways(WithResponse(&resp))
+			assert.Nil(t, err)
+			ids[i] = resp.VGSRequestId
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		assert.NotEmpty(t, id)
+		seen[id] = true
+	}
+	assert.Len(t, seen, len(ids))
+}
+
+func TestWithResponseOnError(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusNotFound, `{"error": "not_found", "error_description": "dummy error"}`, map[string]string{VGSRequestId: "req-1"}))
+	var resp *Response
+	_, err := c.GetTransfer("XF123", WithResponse(&resp))
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "req-1", resp.VGSRequestId)
+}
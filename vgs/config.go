@@ -0,0 +1,278 @@
+package vgs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix prefixes the environment variables read by OptionsFromEnv, e.g.
+// VGS_CLIENT_ID for the client_id setting.
+var EnvPrefix = "VGS_"
+
+// configKeys are the settings of a profile. Settings in secretKeys may
+// instead be read from a file with the _file suffix, e.g. client_secret_file.
+var (
+	configKeys = []string{
+		"client_id", "client_secret", "vault_id", "route_id", "environment",
+		"vault_url", "payment_url", "auth_method", "private_key_file", "key_id",
+		"proxy_username", "proxy_password", "proxy_ca_cert_file", "disable_idempotency_keys",
+	}
+	secretKeys = []string{"client_id", "client_secret", "proxy_username", "proxy_password"}
+)
+
+// ConfigFile is the content of a config file with named profiles:
+//
+//	default_profile: sandbox
+//	profiles:
+//	  sandbox:
+//	    client_id: ACxxx
+//	    client_secret_file: /var/run/secrets/vgs/client-secret
+//	    vault_id: tntxxx
+//	    route_id: xxx
+//	    environment: sandbox
+type ConfigFile struct {
+	DefaultProfile string
+	Profiles       map[string]map[string]string
+}
+
+// OptionsFromEnv reads the options from VGS_ prefixed environment variables,
+// e.g. VGS_CLIENT_ID, VGS_CLIENT_SECRET or VGS_CLIENT_SECRET_FILE.
+func OptionsFromEnv() (*Options, error) {
+	settings := map[string]string{}
+	for _, key := range configKeys {
+		if value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key)); ok {
+			settings[key] = value
+		}
+	}
+	for _, key := range secretKeys {
+		if value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key) + "_FILE"); ok {
+			settings[key+"_file"] = value
+		}
+	}
+	return optionsFromSettings(settings)
+}
+
+// LoadOptions reads the options of profile from a YAML, JSON or TOML config
+// file, chosen by the file extension. An empty profile selects the default
+// profile of the file, or its only profile.
+func LoadOptions(path, profile string) (*Options, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ParseConfigFile(data, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config.Options(profile)
+}
+
+// ParseConfigFile parses a config file in format yaml, yml, json or toml.
+func ParseConfigFile(data []byte, format string) (*ConfigFile, error) {
+	var raw map[string]interface{}
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml", "yml":
+		var document yaml.Node
+		if err = yaml.Unmarshal(data, &document); err == nil && len(document.Content) > 0 {
+			value, ok := yamlValue(document.Content[0]).(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("config must be a table")
+			}
+			raw = value
+		}
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&raw)
+	case "toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config format: %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config := &ConfigFile{Profiles: map[string]map[string]string{}}
+	for key, value := range raw {
+		switch key {
+		case "default_profile":
+			if config.DefaultProfile, _, err = configString(value); err != nil {
+				return nil, fmt.Errorf("default_profile %w", err)
+			}
+		case "profiles":
+			profiles, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("profiles must be a table, got %T", value)
+			}
+			for name, value := range profiles {
+				settings, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("profile %s must be a table, got %T", name, value)
+				}
+				config.Profiles[name] = map[string]string{}
+				for key, value := range settings {
+					setting, ok, err := configString(value)
+					if err != nil {
+						return nil, fmt.Errorf("profile %s: %s %w", name, key, err)
+					}
+					if ok {
+						config.Profiles[name][key] = setting
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown config key: %q", key)
+		}
+	}
+	return config, nil
+}
+
+// configString returns a setting as written in the config file, ok is false
+// for null values, which leave the setting unset. Tables and lists are
+// rejected.
+func configString(value interface{}) (setting string, ok bool, err error) {
+	switch value := value.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return value, true, nil
+	case bool:
+		return strconv.FormatBool(value), true, nil
+	case json.Number:
+		return value.String(), true, nil
+	case int64:
+		return strconv.FormatInt(value, 10), true, nil
+	}
+	return "", false, fmt.Errorf("must be a string, got %T", value)
+}
+
+// yamlValue converts node to tables, lists and the scalars as written, so
+// e.g. 0012 is not read as an octal number.
+func yamlValue(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		table := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			table[node.Content[i].Value] = yamlValue(node.Content[i+1])
+		}
+		return table
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			list[i] = yamlValue(item)
+		}
+		return list
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return nil
+		}
+		return node.Value
+	}
+	return nil
+}
+
+// Options returns the options of profile, see LoadOptions. A profile named
+// after an environment, e.g. live-eu-1, defaults to that environment.
+func (c *ConfigFile) Options(profile string) (*Options, error) {
+	if profile == "" {
+		profile = c.DefaultProfile
+	}
+	if profile == "" && len(c.Profiles) == 1 {
+		for name := range c.Profiles {
+			profile = name
+		}
+	}
+	settings, ok := c.Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile: %q", profile)
+	}
+	options, err := optionsFromSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := LookupEnvironment(Environment(profile)); err == nil && options.Environment == "" {
+		options.Environment = Environment(profile)
+	}
+	return options, nil
+}
+
+func optionsFromSettings(settings map[string]string) (*Options, error) {
+	known := map[string]bool{}
+	for _, key := range configKeys {
+		known[key] = true
+	}
+	for _, key := range secretKeys {
+		known[key+"_file"] = true
+	}
+	var unknown []string
+	for key := range settings {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+	// the secrets read from files must not end up in the caller's map
+	copied := make(map[string]string, len(settings))
+	for key, value := range settings {
+		copied[key] = value
+	}
+	settings = copied
+	for _, key := range secretKeys {
+		path, ok := settings[key+"_file"]
+		if !ok || settings[key] != "" {
+			continue
+		}
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s_file: %w", key, err)
+		}
+		settings[key] = strings.TrimRight(string(secret), "\r\n")
+	}
+
+	options := &Options{
+		ClientID:        settings["client_id"],
+		ClientSecret:    settings["client_secret"],
+		VaultId:         settings["vault_id"],
+		RouteId:         settings["route_id"],
+		Environment:     Environment(settings["environment"]),
+		AuthMethod:      AuthMethod(settings["auth_method"]),
+		PrivateKeyFile:  settings["private_key_file"],
+		KeyID:           settings["key_id"],
+		ProxyUsername:   settings["proxy_username"],
+		ProxyPassword:   settings["proxy_password"],
+		ProxyCACertFile: settings["proxy_ca_cert_file"],
+	}
+	var err error
+	if value := settings["vault_url"]; value != "" {
+		if options.VaultURL, err = url.Parse(value); err != nil {
+			return nil, fmt.Errorf("vault_url: %w", err)
+		}
+	}
+	if value := settings["payment_url"]; value != "" {
+		if options.PaymentURL, err = url.Parse(value); err != nil {
+			return nil, fmt.Errorf("payment_url: %w", err)
+		}
+	}
+	if value := settings["disable_idempotency_keys"]; value != "" {
+		if options.DisableIdempotencyKeys, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("disable_idempotency_keys: %w", err)
+		}
+	}
+	return options, nil
+}
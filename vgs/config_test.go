@@ -0,0 +1,180 @@
+package vgs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "client-secret")
+	assert.Nil(t, os.WriteFile(secretFile, []byte("mounted-secret\n"), 0o600))
+	t.Setenv("VGS_CLIENT_ID", "env-client")
+	t.Setenv("VGS_CLIENT_SECRET_FILE", secretFile)
+	t.Setenv("VGS_VAULT_ID", "tntenv")
+	t.Setenv("VGS_ROUTE_ID", "route")
+	t.Setenv("VGS_ENVIRONMENT", "live")
+	t.Setenv("VGS_DISABLE_IDEMPOTENCY_KEYS", "true")
+
+	options, err := OptionsFromEnv()
+	assert.Nil(t, err)
+	assert.Equal(t, "env-client", options.ClientID)
+	assert.Equal(t, "mounted-secret", options.ClientSecret)
+	assert.Equal(t, "tntenv", options.VaultId)
+	assert.Equal(t, Live, options.Environment)
+	assert.True(t, options.DisableIdempotencyKeys)
+	assert.Nil(t, options.Validate())
+
+	t.Setenv("VGS_DISABLE_IDEMPOTENCY_KEYS", "maybe")
+	_, err = OptionsFromEnv()
+	assert.ErrorContains(t, err, "disable_idempotency_keys")
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "secret")
+	assert.Nil(t, os.WriteFile(secretFile, []byte("file-secret"), 0o600))
+	files := map[string]string{
+		"vgs.yaml": `
+default_profile: sandbox
+profiles:
+  sandbox:
+    client_id: sandbox-client
+    client_secret_file: ` + secretFile + `
+    vault_id: tntsandbox
+    route_id: route
+  live-eu-1:
+    client_id: eu-client
+    client_secret: eu-secret
+    vault_id: tnteu
+    route_id: route
+    disable_idempotency_keys: true
+`,
+		"vgs.json": `{
+  "default_profile": "sandbox",
+  "profiles": {
+    "sandbox": {"client_id": "sandbox-client", "client_secret_file": "` + secretFile + `", "vault_id": "tntsandbox", "route_id": "route"},
+    "live-eu-1": {"client_id": "eu-client", "client_secret": "eu-secret", "vault_id": "tnteu", "route_id": "route", "disable_idempotency_keys": true}
+  }
+}`,
+		"vgs.toml": `
+# VGS profiles
+default_profile = "sandbox"
+
+[profiles.sandbox]
+client_id = "sandbox-client"
+client_secret_file = '` + secretFile + `'
+vault_id = "tntsandbox"
+route_id = "route"
+
+[profiles."live-eu-1"]
+client_id = "eu-client" # comment
+client_secret = """
+eu-secret"""
+vault_id = "tnteu"
+route_id = "route"
+disable_idempotency_keys = true
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+
+		options, err := LoadOptions(path, "")
+		if !assert.Nil(t, err, name) {
+			continue
+		}
+		assert.Equal(t, "sandbox-client", options.ClientID, name)
+		assert.Equal(t, "file-secret", options.ClientSecret, name)
+		assert.Equal(t, Sandbox, options.Environment, name)
+
+		options, err = LoadOptions(path, "live-eu-1")
+		assert.Nil(t, err, name)
+		assert.Equal(t, "eu-secret", options.ClientSecret, name)
+		assert.Equal(t, LiveEU1, options.Environment, name)
+		assert.True(t, options.DisableIdempotencyKeys, name)
+
+		_, err = LoadOptions(path, "missing")
+		assert.ErrorContains(t, err, "unknown profile", name)
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	t.Parallel()
+	_, err := ParseConfigFile([]byte(`{}`), "ini")
+	assert.ErrorContains(t, err, "unsupported config format")
+	_, err = ParseConfigFile([]byte("default_profile = \"sandbox\" trailing"), "toml")
+	assert.NotNil(t, err)
+	_, err = ParseConfigFile([]byte(`{"profile": {}}`), "json")
+	assert.ErrorContains(t, err, "unknown config key")
+
+	config, err := ParseConfigFile([]byte("profiles:\n  test:\n    client_secret_file: /nonexistent\n    vault: typo\n"), "yaml")
+	assert.Nil(t, err)
+	_, err = config.Options("")
+	assert.ErrorContains(t, err, "unknown config keys: vault")
+}
+
+func TestParseConfigFileScalars(t *testing.T) {
+	t.Parallel()
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	assert.Nil(t, os.WriteFile(secretFile, []byte("file-secret"), 0o600))
+	files := map[string]string{
+		"yaml": "profiles:\n  test:\n    client_id: 1234567\n    client_secret:\n    client_secret_file: " + secretFile + "\n    route_id: 0012\n",
+		"json": `{"profiles": {"test": {"client_id": 1234567, "client_secret": null, "client_secret_file": "` + secretFile + `", "route_id": "0012"}}}`,
+	}
+	for format, content := range files {
+		config, err := ParseConfigFile([]byte(content), format)
+		if !assert.Nil(t, err, format) {
+			continue
+		}
+		assert.NotContains(t, config.Profiles["test"], "client_secret", format)
+		options, err := config.Options("test")
+		assert.Nil(t, err, format)
+		assert.Equal(t, "1234567", options.ClientID, format)
+		assert.Equal(t, "file-secret", options.ClientSecret, format)
+		assert.Equal(t, "0012", options.RouteId, format)
+	}
+
+	_, err := ParseConfigFile([]byte("profiles:\n  test:\n    client_id: [a, b]\n"), "yaml")
+	assert.ErrorContains(t, err, "profile test: client_id must be a string")
+	_, err = ParseConfigFile([]byte(`{"default_profile": {}}`), "json")
+	assert.ErrorContains(t, err, "default_profile must be a string")
+
+	config, err := ParseConfigFile([]byte("[profiles.test]\nvault_id = \"tnt#1\" # comment\nroute_id = 12\nkey_id = \"a\\tb\"\n"), "toml")
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"vault_id": "tnt#1", "route_id": "12", "key_id": "a\tb"}, config.Profiles["test"])
+	}
+	_, err = ParseConfigFile([]byte("[[profiles.test]]\nvault_id = \"tnt\"\n"), "toml")
+	assert.ErrorContains(t, err, "profile test must be a table")
+}
+
+func TestConfigFileOptionsKeepsProfile(t *testing.T) {
+	t.Parallel()
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	assert.Nil(t, os.WriteFile(secretFile, []byte("file-secret"), 0o600))
+	config := &ConfigFile{Profiles: map[string]map[string]string{
+		"sandbox": {"client_id": "client", "client_secret_file": secretFile},
+	}}
+	options, err := config.Options("sandbox")
+	assert.Nil(t, err)
+	assert.Equal(t, "file-secret", options.ClientSecret)
+	assert.NotContains(t, config.Profiles["sandbox"], "client_secret")
+}
+
+func TestOptionsValidate(t *testing.T) {
+	t.Parallel()
+	err := (&Options{Environment: "staging", ProxyUsername: "user"}).Validate()
+	var validationError *ValidationError
+	assert.True(t, errors.As(err, &validationError))
+	assert.Len(t, validationError.Problems, 6)
+	assert.ErrorContains(t, err, "client id and client secret is required")
+	assert.ErrorContains(t, err, "vault id is required")
+	assert.ErrorContains(t, err, "route id is required")
+	assert.ErrorContains(t, err, "proxy username and proxy password")
+	assert.True(t, errors.Is(err, errProxyCACertRequired))
+	assert.True(t, errors.Is(err, ErrUnknownEnvironment))
+}
@@ -0,0 +1,108 @@
+package vgs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type Environment string
+
+const (
+	Sandbox Environment = "sandbox"
+	Live    Environment = "live"
+	LiveEU1 Environment = "live-eu-1"
+)
+
+var ErrUnknownEnvironment = errors.New("vgs: unknown environment")
+
+// EnvironmentConfig holds the hosts of an environment. Hosts may contain the
+// {vault} and {route} placeholders, which are replaced with Options.VaultId
+// and Options.RouteId, and a port.
+type EnvironmentConfig struct {
+	// Scheme of the vault, payment and vault API urls, defaults to https.
+	Scheme            string
+	VaultHost         string
+	PaymentHost       string
+	VaultApiHost      string
+	OutboundProxyHost string
+	// Token endpoint, defaults to AuthEndpoint.
+	AuthEndpoint string
+}
+
+var (
+	environmentsMu sync.RWMutex
+	environments   = map[Environment]EnvironmentConfig{
+		Sandbox: defaultEnvironmentConfig(Sandbox),
+		Live:    defaultEnvironmentConfig(Live),
+		LiveEU1: defaultEnvironmentConfig(LiveEU1),
+	}
+)
+
+func defaultEnvironmentConfig(env Environment) EnvironmentConfig {
+	return EnvironmentConfig{
+		VaultHost:         "{vault}." + string(env) + ".verygoodproxy.com",
+		PaymentHost:       "{vault}-{route}." + string(env) + ".verygoodproxy.com",
+		VaultApiHost:      "api." + string(env) + ".verygoodvault.com",
+		OutboundProxyHost: "{vault}." + string(env) + ".verygoodproxy.com",
+	}
+}
+
+// RegisterEnvironment adds an environment, or overrides the hosts of an
+// existing one, e.g. to point the client at a local mock server.
+func RegisterEnvironment(env Environment, config EnvironmentConfig) {
+	environmentsMu.Lock()
+	defer environmentsMu.Unlock()
+	environments[normalizeEnvironment(env)] = config
+}
+
+// LookupEnvironment returns the config of a registered environment.
+func LookupEnvironment(env Environment) (EnvironmentConfig, error) {
+	environmentsMu.RLock()
+	defer environmentsMu.RUnlock()
+	config, ok := environments[normalizeEnvironment(env)]
+	if !ok {
+		return EnvironmentConfig{}, fmt.Errorf("%w: %q", ErrUnknownEnvironment, env)
+	}
+	return config, nil
+}
+
+func normalizeEnvironment(env Environment) Environment {
+	return Environment(strings.ToLower(string(env)))
+}
+
+// environment returns the config of o.Environment, defaulting to Sandbox.
+func (o *Options) environment() (EnvironmentConfig, error) {
+	env := o.Environment
+	if env == "" {
+		env = Sandbox
+	}
+	return LookupEnvironment(env)
+}
+
+// url builds the url of host, replacing its placeholders.
+func (o *Options) url(config EnvironmentConfig, host string) (*url.URL, error) {
+	scheme := config.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host = strings.NewReplacer("{vault}", o.VaultId, "{route}", o.RouteId).Replace(host)
+	return url.Parse(scheme + "://" + host)
+}
+
+func (o *Options) authEndpoint() string {
+	config, err := o.environment()
+	if err != nil || config.AuthEndpoint == "" {
+		return AuthEndpoint
+	}
+	return config.AuthEndpoint
+}
+
+// hasPort reports whether host ends with a port.
+func hasPort(host string) bool {
+	_, _, err := net.SplitHostPort(host)
+	return err == nil
+}
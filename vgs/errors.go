@@ -1,10 +1,42 @@
 package vgs
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
+var (
+	// ErrAuthentication matches every AuthError.
+	ErrAuthentication = errors.New("authentication failed")
+	// ErrInvalidClient means the client id or secret is wrong.
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// ErrUnauthorizedClient means the client may not use the grant type.
+	ErrUnauthorizedClient = errors.New("client is not authorized for the grant type")
+	// ErrInvalidGrant means the refresh token is expired or revoked.
+	ErrInvalidGrant = errors.New("invalid grant")
+	// ErrAuthRateLimited means the auth endpoint throttled the client.
+	ErrAuthRateLimited = errors.New("authentication rate limited")
+	// ErrAuthServer means the auth endpoint failed, retrying may succeed.
+	ErrAuthServer = errors.New("authentication server error")
+)
+
+var (
+	// ErrAPI matches every APIError.
+	ErrAPI          = errors.New("api error")
+	ErrNotFound     = errors.New("resource not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	// ErrDeclined means the payment gateway declined the transfer.
+	ErrDeclined    = errors.New("declined")
+	ErrRateLimited = errors.New("rate limited")
+	ErrServer      = errors.New("server error")
+)
+
 type ErrorDetail struct {
 	Code   string `json:"code,omitempty"`
 	Detail string `json:"detail,omitempty"`
@@ -26,3 +58,185 @@ func (e VGSError) Error() string {
 	}
 	return fmt.Sprintf("Response errors:\n %v", strings.Join(msgs, "\n"))
 }
+
+// APIError is returned for every non 2xx API response, errors.As with
+// VGSError still works. Log VGSRequestId when contacting VGS support.
+type APIError struct {
+	VGSError
+	StatusCode   int
+	VGSRequestId string
+	TraceId      string
+	// Body of the response, e.g. the HTML page of a load balancer.
+	RawBody []byte
+}
+
+func NewAPIError(r *Response) *APIError {
+	apiError := &APIError{
+		StatusCode:   r.StatusCode,
+		VGSRequestId: r.VGSRequestId,
+		TraceId:      r.TraceId,
+		RawBody:      r.RawBody,
+	}
+	if err := json.Unmarshal(r.RawBody, &apiError.VGSError); err != nil {
+		apiError.VGSError = VGSError{ErrorDescription: http.StatusText(r.StatusCode)}
+	}
+	return apiError
+}
+
+func (e *APIError) Error() string {
+	msg := e.VGSError.Error()
+	if e.VGSRequestId != "" {
+		return fmt.Sprintf("%s (status %d, request id %s)", msg, e.StatusCode, e.VGSRequestId)
+	}
+	return fmt.Sprintf("%s (status %d)", msg, e.StatusCode)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.VGSError
+}
+
+// Kind returns the sentinel error the failure is classified as.
+func (e *APIError) Kind() error {
+	switch {
+	case e.hasCode("declined"):
+		return ErrDeclined
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return ErrAPI
+}
+
+func (e *APIError) Is(target error) bool {
+	return target == ErrAPI || target == e.Kind()
+}
+
+// Retryable reports whether repeating the call may succeed.
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (e *APIError) hasCode(code string) bool {
+	if strings.Contains(strings.ToLower(e.ErrorCode), code) {
+		return true
+	}
+	for _, detail := range e.Errors {
+		if strings.Contains(strings.ToLower(detail.Code), code) {
+			return true
+		}
+	}
+	return false
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrAuthRateLimited)
+}
+
+func IsValidation(err error) bool {
+	return errors.Is(err, ErrValidation)
+}
+
+func IsDeclined(err error) bool {
+	return errors.Is(err, ErrDeclined)
+}
+
+// IsRetryable reports whether err is a transient API or authentication
+// failure.
+func IsRetryable(err error) bool {
+	var apiError *APIError
+	if errors.As(err, &apiError) {
+		return apiError.Retryable()
+	}
+	return errors.Is(err, ErrAuthRateLimited) || errors.Is(err, ErrAuthServer)
+}
+
+// AuthError is returned when the auth endpoint refuses to issue a token. Use
+// errors.Is with the Err* authentication sentinels to tell bad credentials
+// from outages.
+type AuthError struct {
+	StatusCode       int
+	VGSRequestId     string
+	ErrorCode        string
+	ErrorDescription string
+	// Body of the response, kept when it is not a JSON error.
+	RawBody string
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("authentication error (status %d", e.StatusCode)
+	if e.ErrorCode != "" {
+		msg += ", " + e.ErrorCode
+	}
+	if e.VGSRequestId != "" {
+		msg += ", request id " + e.VGSRequestId
+	}
+	msg += ")"
+	if e.ErrorDescription != "" {
+		msg += ": " + e.ErrorDescription
+	}
+	return msg
+}
+
+// Kind returns the sentinel error the failure is classified as.
+func (e *AuthError) Kind() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrAuthRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrAuthServer
+	case e.ErrorCode == "invalid_client":
+		return ErrInvalidClient
+	case e.ErrorCode == "unauthorized_client":
+		return ErrUnauthorizedClient
+	case e.ErrorCode == "invalid_grant":
+		return ErrInvalidGrant
+	}
+	return ErrAuthentication
+}
+
+func (e *AuthError) Is(target error) bool {
+	return target == ErrAuthentication || target == e.Kind()
+}
+
+// ValidationError lists every problem found by Options.Validate.
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.Error()
+	}
+	return "invalid options: " + strings.Join(problems, "; ")
+}
+
+// Is matches any of the problems, e.g. ErrUnknownEnvironment.
+func (e *ValidationError) Is(target error) bool {
+	for _, problem := range e.Problems {
+		if errors.Is(problem, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package vgs
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorClassification(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		status    int
+		body      string
+		kind      error
+		retryable bool
+	}{
+		{http.StatusNotFound, `{"errors": [{"code": "not_found", "detail": "dummy error"}]}`, ErrNotFound, false},
+		{http.StatusUnprocessableEntity, `{"errors": [{"code": "invalid_amount", "detail": "dummy error"}]}`, ErrValidation, false},
+		{http.StatusUnprocessableEntity, `{"errors": [{"code": "card_declined", "detail": "dummy error"}]}`, ErrDeclined, false},
+		{http.StatusTooManyRequests, `{"error": "rate_limited", "error_description": "dummy error"}`, ErrRateLimited, true},
+		{http.StatusConflict, `{"error": "conflict", "error_description": "dummy error"}`, ErrConflict, false},
+		{http.StatusBadGateway, `<html><body>502 Bad Gateway</body></html>`, ErrServer, true},
+	}
+	for _, testCase := range testCases {
+		c := NewMockClientWithHandler(newMockHandler(testCase.status, testCase.body, map[string]string{
+			VGSRequestId: "req-1",
+			TraceId:      "trace-1",
+		}))
+		_, err := c.GetTransfer("XF123")
+		assert.ErrorIs(t, err, ErrAPI)
+		assert.ErrorIs(t, err, testCase.kind)
+		assert.Equal(t, testCase.retryable, IsRetryable(err))
+		assert.Contains(t, err.Error(), "req-1")
+
+		var apiError *APIError
+		assert.ErrorAs(t, err, &apiError)
+		assert.Equal(t, testCase.status, apiError.StatusCode)
+		assert.Equal(t, "req-1", apiError.VGSRequestId)
+		assert.Equal(t, "trace-1", apiError.TraceId)
+		assert.Equal(t, testCase.body, string(apiError.RawBody))
+	}
+}
+
+func TestAPIErrorNonJSONBody(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusBadGateway, `<html>Bad Gateway</html>`, nil))
+	_, err := c.GetGateways()
+	assert.ErrorContains(t, err, "Bad Gateway")
+	assert.NotContains(t, err.Error(), "invalid character")
+}
+
+func TestAPIErrorHelpers(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsNotFound(&APIError{StatusCode: http.StatusNotFound}))
+	assert.True(t, IsValidation(&APIError{StatusCode: http.StatusBadRequest}))
+	assert.True(t, IsDeclined(&APIError{StatusCode: http.StatusPaymentRequired, VGSError: VGSError{ErrorCode: "declined"}}))
+	assert.True(t, IsRateLimited(&AuthError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, IsRetryable(&AuthError{StatusCode: http.StatusServiceUnavailable}))
+	assert.False(t, IsRetryable(&AuthError{StatusCode: http.StatusUnauthorized, ErrorCode: "invalid_client"}))
+	assert.False(t, IsNotFound(errors.New("dummy")))
+
+	var vgsError VGSError
+	assert.True(t, errors.As(&APIError{VGSError: VGSError{ErrorCode: "dummy"}}, &vgsError))
+	assert.Equal(t, "dummy", vgsError.ErrorCode)
+}
@@ -1,6 +1,11 @@
 package vgs
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
 
 type ContactAddress struct {
 	Name       string `json:"name,omitempty"`
@@ -47,18 +52,82 @@ type FinancialInstrument struct {
 	Data FinancialInstrumentData `json:"data,omitempty"`
 }
 
-func (c *Client) GetFinancialInstruments() (*FinancialInstruments, error) {
+type FinancialInstrumentsFilter struct {
+	SubAccountID string
+	// Only return instruments created at or after this time.
+	CreatedAfter time.Time
+	// Only return instruments created at or before this time.
+	CreatedBefore time.Time
+	// Card brand, e.g. visa or mastercard.
+	CardBrand string
+}
+
+func (f *FinancialInstrumentsFilter) Values() url.Values {
+	values := url.Values{}
+	if f == nil {
+		return values
+	}
+	if f.SubAccountID != "" {
+		values.Set("sub_account_id", f.SubAccountID)
+	}
+	if !f.CreatedAfter.IsZero() {
+		values.Set("created_at[gte]", f.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if !f.CreatedBefore.IsZero() {
+		values.Set("created_at[lte]", f.CreatedBefore.UTC().Format(time.RFC3339))
+	}
+	if f.CardBrand != "" {
+		values.Set("card_brand", f.CardBrand)
+	}
+	return values
+}
+
+func financialInstrumentUri(id string) string {
+	return fmt.Sprintf("/financial_instruments/%s", url.PathEscape(id))
+}
+
+func (c *Client) GetFinancialInstruments(options ...RequestOption) (*FinancialInstruments, error) {
+	return c.GetFinancialInstrumentsContext(c.Ctx, options...)
+}
+
+func (c *Client) GetFinancialInstrumentsContext(ctx context.Context, options ...RequestOption) (*FinancialInstruments, error) {
+	return c.FilterFinancialInstrumentsContext(ctx, nil, options...)
+}
+
+func (c *Client) FilterFinancialInstruments(filter *FinancialInstrumentsFilter, options ...RequestOption) (*FinancialInstruments, error) {
+	return c.FilterFinancialInstrumentsContext(c.Ctx, filter, options...)
+}
+
+func (c *Client) FilterFinancialInstrumentsContext(ctx context.Context, filter *FinancialInstrumentsFilter, options ...RequestOption) (*FinancialInstruments, error) {
+	options = append([]RequestOption{WithQuery(filter.Values())}, options...)
 	instruments := &FinancialInstruments{}
-	_, err := c.Get("/financial_instruments", instruments)
+	_, err := c.GetContext(ctx, "/financial_instruments", instruments, options...)
 	if err != nil {
 		return nil, err
 	}
 	return instruments, nil
 }
 
-func (c *Client) CreateFinancialInstrument(body interface{}) (*FinancialInstrument, error) {
+func (c *Client) GetFinancialInstrument(id string, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.GetFinancialInstrumentContext(c.Ctx, id, options...)
+}
+
+func (c *Client) GetFinancialInstrumentContext(ctx context.Context, id string, options ...RequestOption) (*FinancialInstrument, error) {
+	resp := &FinancialInstrument{}
+	_, err := c.GetContext(ctx, financialInstrumentUri(id), resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateFinancialInstrument(body interface{}, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.CreateFinancialInstrumentContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreateFinancialInstrumentContext(ctx context.Context, body interface{}, options ...RequestOption) (*FinancialInstrument, error) {
 	resp := &FinancialInstrument{}
-	_, err := c.Post("/financial_instruments", body, resp)
+	_, err := c.PostContext(ctx, "/financial_instruments", body, resp, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,14 +138,64 @@ type CreatePSPTokenRequest struct {
 	PspToken *PspToken `json:"psp_token,omitempty"`
 }
 
-func (c *Client) CreatePSPToken(psp, id string) (*FinancialInstrument, error) {
-	return c.CreateFinancialInstrument(&CreatePSPTokenRequest{PspToken: &PspToken{Id: id, Psp: psp}})
+func (c *Client) CreatePSPToken(psp, id string, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.CreatePSPTokenContext(c.Ctx, psp, id, options...)
+}
+
+func (c *Client) CreatePSPTokenContext(ctx context.Context, psp, id string, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.CreateFinancialInstrumentContext(ctx, &CreatePSPTokenRequest{PspToken: &PspToken{Id: id, Psp: psp}}, options...)
 }
 
 type CreatePaymentCardRequest struct {
 	Card *Card `json:"card,omitempty"`
 }
 
-func (c *Client) CreatePaymentCard(body *CreatePaymentCardRequest) (*FinancialInstrument, error) {
-	return c.CreateFinancialInstrument(body)
+func (c *Client) CreatePaymentCard(body *CreatePaymentCardRequest, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.CreatePaymentCardContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreatePaymentCardContext(ctx context.Context, body *CreatePaymentCardRequest, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.CreateFinancialInstrumentContext(ctx, body, options...)
+}
+
+func (c *Client) UpdateFinancialInstrument(id string, patch interface{}, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.UpdateFinancialInstrumentContext(c.Ctx, id, patch, options...)
+}
+
+func (c *Client) UpdateFinancialInstrumentContext(ctx context.Context, id string, patch interface{}, options ...RequestOption) (*FinancialInstrument, error) {
+	resp := &FinancialInstrument{}
+	_, err := c.PatchContext(ctx, financialInstrumentUri(id), patch, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+type UpdatePaymentCardRequest struct {
+	Card *CardUpdate `json:"card,omitempty"`
+}
+
+// CardUpdate holds the card fields that can be changed on a stored instrument.
+type CardUpdate struct {
+	Name           string          `json:"name,omitempty"`
+	ExpMonth       int             `json:"exp_month,omitempty"`
+	ExpYear        int             `json:"exp_year,omitempty"`
+	BillingAddress *ContactAddress `json:"billing_address,omitempty"`
+}
+
+func (c *Client) UpdatePaymentCard(id string, body *UpdatePaymentCardRequest, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.UpdatePaymentCardContext(c.Ctx, id, body, options...)
+}
+
+func (c *Client) UpdatePaymentCardContext(ctx context.Context, id string, body *UpdatePaymentCardRequest, options ...RequestOption) (*FinancialInstrument, error) {
+	return c.UpdateFinancialInstrumentContext(ctx, id, body, options...)
+}
+
+func (c *Client) DeleteFinancialInstrument(id string, options ...RequestOption) error {
+	return c.DeleteFinancialInstrumentContext(c.Ctx, id, options...)
+}
+
+func (c *Client) DeleteFinancialInstrumentContext(ctx context.Context, id string, options ...RequestOption) error {
+	_, err := c.DeleteContext(ctx, financialInstrumentUri(id), nil, options...)
+	return err
 }
@@ -1,8 +1,10 @@
 package vgs
 
 import (
+	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,3 +29,59 @@ func TestGetFinancialInstrumentsBadRequest(t *testing.T) {
 	assert.Nil(t, gateways)
 	assert.ErrorContains(t, err, "dummy")
 }
+
+func TestFilterFinancialInstruments(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/financial_instruments", r.URL.Path)
+		assert.Equal(t, "SA1", r.URL.Query().Get("sub_account_id"))
+		assert.Equal(t, "2023-01-01T00:00:00Z", r.URL.Query().Get("created_at[gte]"))
+		assert.Empty(t, r.URL.Query().Get("created_at[lte]"))
+		assert.Equal(t, "visa", r.URL.Query().Get("card_brand"))
+		w.Write([]byte(`{"data": [{"id": "dummy"}]}`))
+	})
+	instruments, err := c.FilterFinancialInstruments(&FinancialInstrumentsFilter{
+		SubAccountID: "SA1",
+		CreatedAfter: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		CardBrand:    "visa",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, instruments.Data, 1)
+}
+
+func TestGetFinancialInstrument(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/financial_instruments/FI123", http.StatusOK, `{"data": {"id": "FI123"}}`))
+	instrument, err := c.GetFinancialInstrument("FI123")
+	assert.Nil(t, err)
+	assert.Equal(t, "FI123", instrument.Data.ID)
+}
+
+func TestUpdatePaymentCard(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/financial_instruments/FI123", r.URL.Path)
+		var body map[string]map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"exp_month": float64(12), "exp_year": float64(2030)}, body["card"])
+		w.Write([]byte(`{"data": {"id": "FI123", "card": {"exp_month": 12, "exp_year": 2030}}}`))
+	})
+	instrument, err := c.UpdatePaymentCard("FI123", &UpdatePaymentCardRequest{Card: &CardUpdate{ExpMonth: 12, ExpYear: 2030}})
+	assert.Nil(t, err)
+	assert.Equal(t, 2030, instrument.Data.Card.ExpYear)
+}
+
+func TestDeleteFinancialInstrument(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodDelete, "/financial_instruments/FI123", http.StatusNoContent, ""))
+	err := c.DeleteFinancialInstrument("FI123")
+	assert.Nil(t, err)
+}
+
+func TestDeleteFinancialInstrumentNotFound(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusNotFound, `{"errors": [{"code": "not_found", "detail": "dummy error"}]}`, nil))
+	err := c.DeleteFinancialInstrument("FI123")
+	assert.ErrorContains(t, err, "dummy")
+}
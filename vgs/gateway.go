@@ -1,6 +1,12 @@
 package vgs
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
 
 type Gateway struct {
 	Type_ string `json:"type"`
@@ -10,24 +16,137 @@ type Gateway struct {
 	DefaultCurrency string `json:"default_currency"`
 	// Is this gateway the default gateway or not.  A default gateway is needed and will be used when a transfer without matching any routing rule created. There could be only one default gateway at the same time. When a new gateway created as the default gateway, the old default gateway will no longer be the default gateway anymore.
 	DefaultGateway bool `json:"default_gateway,omitempty"`
-	// Any specific keys passed through to the gateway configuration. Refer to docs
-	Config *interface{} `json:"config"`
+	// Any specific keys passed through to the gateway configuration. Decoded
+	// into a typed config based on Type_, or RawGatewayConfig for unknown types.
+	Config GatewayConfig `json:"config"`
 	// Creation time, in UTC.
 	CreatedAt time.Time `json:"created_at"`
 	// Last time psp token was updated, in UTC.
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+func (g *Gateway) UnmarshalJSON(data []byte) error {
+	type gateway Gateway
+	aux := &struct {
+		*gateway
+		Config json.RawMessage `json:"config"`
+	}{gateway: (*gateway)(g)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	config, err := DecodeGatewayConfig(g.Type_, aux.Config)
+	if err != nil {
+		return err
+	}
+	g.Config = config
+	return nil
+}
+
 type Gateways struct {
 	Response
 	Data []Gateway `json:"data"`
 }
 
-func (c *Client) GetGateways() (*Gateways, error) {
+type GatewayItem struct {
+	Data Gateway `json:"data"`
+}
+
+type CreateGatewayRequest struct {
+	// Gateway type. Defaults to the type of Config.
+	Type_           string        `json:"type"`
+	Id              string        `json:"id,omitempty"`
+	DefaultCurrency string        `json:"default_currency,omitempty"`
+	DefaultGateway  bool          `json:"default_gateway,omitempty"`
+	Config          GatewayConfig `json:"config,omitempty"`
+}
+
+type UpdateGatewayRequest struct {
+	DefaultCurrency string        `json:"default_currency,omitempty"`
+	DefaultGateway  *bool         `json:"default_gateway,omitempty"`
+	Config          GatewayConfig `json:"config,omitempty"`
+}
+
+func gatewayUri(id string) string {
+	return fmt.Sprintf("/gateways/%s", url.PathEscape(id))
+}
+
+func (c *Client) GetGateways(options ...RequestOption) (*Gateways, error) {
+	return c.GetGatewaysContext(c.Ctx, options...)
+}
+
+func (c *Client) GetGatewaysContext(ctx context.Context, options ...RequestOption) (*Gateways, error) {
 	gateways := &Gateways{}
-	_, err := c.Get("/gateways", gateways)
+	_, err := c.GetContext(ctx, "/gateways", gateways, options...)
 	if err != nil {
 		return nil, err
 	}
 	return gateways, nil
 }
+
+func (c *Client) GetGateway(id string, options ...RequestOption) (*GatewayItem, error) {
+	return c.GetGatewayContext(c.Ctx, id, options...)
+}
+
+func (c *Client) GetGatewayContext(ctx context.Context, id string, options ...RequestOption) (*GatewayItem, error) {
+	resp := &GatewayItem{}
+	_, err := c.GetContext(ctx, gatewayUri(id), resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateGateway(body *CreateGatewayRequest, options ...RequestOption) (*GatewayItem, error) {
+	return c.CreateGatewayContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreateGatewayContext(ctx context.Context, body *CreateGatewayRequest, options ...RequestOption) (*GatewayItem, error) {
+	if body == nil {
+		body = &CreateGatewayRequest{}
+	}
+	if body.Type_ == "" && body.Config != nil {
+		// default the type on a copy, body is the caller's
+		typed := *body
+		typed.Type_ = body.Config.GatewayType()
+		body = &typed
+	}
+	resp := &GatewayItem{}
+	_, err := c.PostContext(ctx, "/gateways", body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) UpdateGateway(id string, body *UpdateGatewayRequest, options ...RequestOption) (*GatewayItem, error) {
+	return c.UpdateGatewayContext(c.Ctx, id, body, options...)
+}
+
+func (c *Client) UpdateGatewayContext(ctx context.Context, id string, body *UpdateGatewayRequest, options ...RequestOption) (*GatewayItem, error) {
+	resp := &GatewayItem{}
+	_, err := c.PatchContext(ctx, gatewayUri(id), body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeleteGateway(id string, options ...RequestOption) error {
+	return c.DeleteGatewayContext(c.Ctx, id, options...)
+}
+
+func (c *Client) DeleteGatewayContext(ctx context.Context, id string, options ...RequestOption) error {
+	_, err := c.DeleteContext(ctx, gatewayUri(id), nil, options...)
+	return err
+}
+
+// SetDefaultGateway makes the gateway the default one. The previous default
+// gateway is unset by the API.
+func (c *Client) SetDefaultGateway(id string, options ...RequestOption) (*GatewayItem, error) {
+	return c.SetDefaultGatewayContext(c.Ctx, id, options...)
+}
+
+func (c *Client) SetDefaultGatewayContext(ctx context.Context, id string, options ...RequestOption) (*GatewayItem, error) {
+	isDefault := true
+	return c.UpdateGatewayContext(ctx, id, &UpdateGatewayRequest{DefaultGateway: &isDefault}, options...)
+}
@@ -0,0 +1,112 @@
+package vgs
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+const (
+	GatewayStripe       = "stripe"
+	GatewayAdyen        = "adyen"
+	GatewayBraintree    = "braintree"
+	GatewayAuthorizeNet = "authorize_net"
+	GatewayCheckout     = "checkout"
+)
+
+type GatewayConfig interface {
+	GatewayType() string
+}
+
+type StripeConfig struct {
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+func (StripeConfig) GatewayType() string { return GatewayStripe }
+
+type AdyenConfig struct {
+	ApiKey          string `json:"api_key,omitempty"`
+	MerchantAccount string `json:"merchant_account,omitempty"`
+	// Prefix of the live endpoint url, required for live environment only.
+	LiveUrlPrefix string `json:"live_url_prefix,omitempty"`
+}
+
+func (AdyenConfig) GatewayType() string { return GatewayAdyen }
+
+type BraintreeConfig struct {
+	MerchantId        string `json:"merchant_id,omitempty"`
+	MerchantAccountId string `json:"merchant_account_id,omitempty"`
+	PublicKey         string `json:"public_key,omitempty"`
+	PrivateKey        string `json:"private_key,omitempty"`
+}
+
+func (BraintreeConfig) GatewayType() string { return GatewayBraintree }
+
+type AuthorizeNetConfig struct {
+	ApiLoginId     string `json:"api_login_id,omitempty"`
+	TransactionKey string `json:"transaction_key,omitempty"`
+}
+
+func (AuthorizeNetConfig) GatewayType() string { return GatewayAuthorizeNet }
+
+type CheckoutConfig struct {
+	SecretKey           string `json:"secret_key,omitempty"`
+	ProcessingChannelId string `json:"processing_channel_id,omitempty"`
+}
+
+func (CheckoutConfig) GatewayType() string { return GatewayCheckout }
+
+// RawGatewayConfig keeps the config of gateway types without a typed struct.
+type RawGatewayConfig struct {
+	Type string
+	Raw  json.RawMessage
+}
+
+func (r RawGatewayConfig) GatewayType() string { return r.Type }
+
+func (r RawGatewayConfig) MarshalJSON() ([]byte, error) {
+	if len(r.Raw) == 0 {
+		return []byte("null"), nil
+	}
+	return r.Raw, nil
+}
+
+var (
+	gatewayConfigsMu sync.RWMutex
+	gatewayConfigs   = map[string]func() GatewayConfig{
+		GatewayStripe:       func() GatewayConfig { return &StripeConfig{} },
+		GatewayAdyen:        func() GatewayConfig { return &AdyenConfig{} },
+		GatewayBraintree:    func() GatewayConfig { return &BraintreeConfig{} },
+		GatewayAuthorizeNet: func() GatewayConfig { return &AuthorizeNetConfig{} },
+		GatewayCheckout:     func() GatewayConfig { return &CheckoutConfig{} },
+	}
+)
+
+// RegisterGatewayConfig registers a typed config for a gateway type. The
+// factory must return a pointer json can decode into.
+func RegisterGatewayConfig(gatewayType string, factory func() GatewayConfig) {
+	gatewayConfigsMu.Lock()
+	defer gatewayConfigsMu.Unlock()
+	gatewayConfigs[gatewayType] = factory
+}
+
+// DecodeGatewayConfig decodes a raw gateway config into the typed config
+// registered for gatewayType, falling back to RawGatewayConfig.
+func DecodeGatewayConfig(gatewayType string, data json.RawMessage) (GatewayConfig, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	gatewayConfigsMu.RLock()
+	factory, ok := gatewayConfigs[gatewayType]
+	gatewayConfigsMu.RUnlock()
+	if !ok {
+		raw := make(json.RawMessage, len(data))
+		copy(raw, data)
+		return &RawGatewayConfig{Type: gatewayType, Raw: raw}, nil
+	}
+	config := factory()
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
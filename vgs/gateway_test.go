@@ -1,6 +1,7 @@
 package vgs
 
 import (
+	"io"
 	"net/http"
 	"testing"
 
@@ -25,3 +26,76 @@ func TestGetGatewaysBadRequest(t *testing.T) {
 	assert.Nil(t, gateways)
 	assert.ErrorContains(t, err, "dummy")
 }
+
+func TestGatewayConfigDecoding(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": [
+		{"id": "GW1", "type": "stripe", "config": {"secret_key": "sk_test"}},
+		{"id": "GW2", "type": "adyen", "config": {"api_key": "key", "merchant_account": "acc"}},
+		{"id": "GW3", "type": "unknown_psp", "config": {"foo": "bar"}},
+		{"id": "GW4", "type": "braintree", "config": null}
+	]}`, nil))
+	gateways, err := c.GetGateways()
+	assert.Nil(t, err)
+	assert.Len(t, gateways.Data, 4)
+	assert.Equal(t, &StripeConfig{SecretKey: "sk_test"}, gateways.Data[0].Config)
+	assert.Equal(t, &AdyenConfig{ApiKey: "key", MerchantAccount: "acc"}, gateways.Data[1].Config)
+	raw, ok := gateways.Data[2].Config.(*RawGatewayConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "unknown_psp", raw.GatewayType())
+	assert.JSONEq(t, `{"foo": "bar"}`, string(raw.Raw))
+	assert.Nil(t, gateways.Data[3].Config)
+}
+
+func TestCreateGateway(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/gateways", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"type": "checkout", "id": "GW1", "config": {"secret_key": "sk"}}`, string(body))
+		w.Write([]byte(`{"data": {"id": "GW1", "type": "checkout", "config": {"secret_key": "sk"}}}`))
+	})
+	body := &CreateGatewayRequest{Id: "GW1", Config: &CheckoutConfig{SecretKey: "sk"}}
+	gateway, err := c.CreateGateway(body)
+	assert.Nil(t, err)
+	assert.Empty(t, body.Type_)
+	assert.Equal(t, "GW1", gateway.Data.Id)
+	assert.Equal(t, &CheckoutConfig{SecretKey: "sk"}, gateway.Data.Config)
+}
+
+func TestCreateGatewayNilBody(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodPost, "/gateways", http.StatusOK, `{"data": {"id": "GW1"}}`))
+	gateway, err := c.CreateGateway(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "GW1", gateway.Data.Id)
+}
+
+func TestGetGateway(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/gateways/GW1", http.StatusOK, `{"data": {"id": "GW1", "type": "authorize_net", "config": {"api_login_id": "login"}}}`))
+	gateway, err := c.GetGateway("GW1")
+	assert.Nil(t, err)
+	assert.Equal(t, &AuthorizeNetConfig{ApiLoginId: "login"}, gateway.Data.Config)
+}
+
+func TestSetDefaultGateway(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, "/gateways/GW1", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"default_gateway": true}`, string(body))
+		w.Write([]byte(`{"data": {"id": "GW1", "default_gateway": true}}`))
+	})
+	gateway, err := c.SetDefaultGateway("GW1")
+	assert.Nil(t, err)
+	assert.True(t, gateway.Data.DefaultGateway)
+}
+
+func TestDeleteGateway(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodDelete, "/gateways/GW1", http.StatusNoContent, ""))
+	assert.Nil(t, c.DeleteGateway("GW1"))
+}
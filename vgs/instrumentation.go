@@ -0,0 +1,187 @@
+package vgs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Hooks observe the client for instrumentation, nil hooks are skipped.
+// Calls themselves are observed with a Middleware.
+type Hooks struct {
+	// OnRetry is called before a failed attempt is retried, resp is nil for
+	// network errors.
+	OnRetry func(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error)
+	// OnTokenFetch is called after every token request of an
+	// OAuthAuthenticator.
+	OnTokenFetch func(ctx context.Context, event TokenFetchEvent)
+}
+
+// TokenFetchEvent describes a token request.
+type TokenFetchEvent struct {
+	// client_credentials or refresh_token
+	GrantType string
+	Start     time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+// AddHooks adds hooks to the client and to the OAuthAuthenticator the client
+// created. An Options.Authenticator may be shared by several clients, add
+// the hooks to it once instead. Add hooks before using the client.
+func (c *Client) AddHooks(hooks Hooks) {
+	c.Options.Hooks = append(c.Options.Hooks, hooks)
+	if c.authenticator != nil {
+		c.authenticator.Hooks = append(c.authenticator.Hooks, hooks)
+	}
+}
+
+func (o *Options) onRetry(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error) {
+	for _, hooks := range o.Hooks {
+		if hooks.OnRetry != nil {
+			hooks.OnRetry(ctx, req, attempt, resp, err)
+		}
+	}
+}
+
+// WithOperation names the call for instrumentation, see OperationName.
+func WithOperation(name string) RequestOption {
+	return func(r *Request) {
+		r.operation = name
+	}
+}
+
+// requestKey is the context key of the Request of a call, so hooks only
+// given the http.Request can name the call.
+type requestKey struct{}
+
+// callRequest returns request, or the Request of the call req belongs to.
+func callRequest(request *Request, req *http.Request) *Request {
+	if request == nil {
+		request, _ = req.Context().Value(requestKey{}).(*Request)
+	}
+	return request
+}
+
+// OperationName names a call for instrumentation. Unless set with
+// WithOperation it is derived from the method and path, e.g.
+// vgs.financial_instruments.create for POST /financial_instruments or
+// vgs.transfers.capture for POST /transfers/{id}/capture. request may be nil.
+func OperationName(request *Request, req *http.Request) string {
+	request = callRequest(request, req)
+	if request != nil && request.operation != "" {
+		return request.operation
+	}
+	segments := pathSegments(req.URL.Path)
+	if request != nil && request.unauthenticated {
+		return "vgs.vault." + strings.ToLower(req.Method)
+	}
+	if len(segments) == 0 {
+		return "vgs." + strings.ToLower(req.Method)
+	}
+	name := "vgs." + segments[0]
+	switch {
+	case len(segments) > 2:
+		for i := 2; i < len(segments); i += 2 {
+			name += "." + segments[i]
+		}
+	case len(segments) == 1 && req.Method == http.MethodGet:
+		name += ".list"
+	case len(segments) == 1 && req.Method == http.MethodPost:
+		name += ".create"
+	case req.Method == http.MethodGet:
+		name += ".get"
+	case req.Method == http.MethodPatch || req.Method == http.MethodPut:
+		name += ".update"
+	default:
+		name += "." + strings.ToLower(req.Method)
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// VaultCall reports whether the call is sent by a VaultClient to an inbound
+// route, which passes it on to the upstream. request may be nil.
+func VaultCall(request *Request, req *http.Request) bool {
+	request = callRequest(request, req)
+	return request != nil && request.unauthenticated
+}
+
+// Route labels a call for metrics with RouteTemplate of its path. VaultClient
+// paths are not API routes and may carry card data, their calls are labeled
+// with the WithOperation name or "vault". request may be nil.
+func Route(request *Request, req *http.Request) string {
+	request = callRequest(request, req)
+	if VaultCall(request, req) {
+		if request.operation != "" {
+			return request.operation
+		}
+		return "vault"
+	}
+	return RouteTemplate(req.URL.Path)
+}
+
+// RouteTemplate replaces the ids in an API path with {id}, e.g.
+// /transfers/{id}/capture, to keep metric labels bounded.
+func RouteTemplate(path string) string {
+	segments := pathSegments(path)
+	for i := 1; i < len(segments); i += 2 {
+		segments[i] = "{id}"
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func pathSegments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// ErrorClass classifies err for metrics, e.g. not_found, rate_limited,
+// auth_invalid_client, timeout or network. It is empty for a nil err.
+func ErrorClass(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	for _, class := range []struct {
+		err  error
+		name string
+	}{
+		{ErrInvalidClient, "auth_invalid_client"},
+		{ErrUnauthorizedClient, "auth_unauthorized_client"},
+		{ErrInvalidGrant, "auth_invalid_grant"},
+		{ErrAuthRateLimited, "auth_rate_limited"},
+		{ErrAuthServer, "auth_server"},
+		{ErrAuthentication, "auth"},
+		{ErrDeclined, "declined"},
+		{ErrNotFound, "not_found"},
+		{ErrUnauthorized, "unauthorized"},
+		{ErrForbidden, "forbidden"},
+		{ErrConflict, "conflict"},
+		{ErrValidation, "validation"},
+		{ErrRateLimited, "rate_limited"},
+		{ErrServer, "server"},
+		{ErrAPI, "api"},
+	} {
+		if errors.Is(err, class.err) {
+			return class.name
+		}
+	}
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	}
+	return "other"
+}
@@ -0,0 +1,128 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperationName(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		method, path, expected string
+	}{
+		{http.MethodPost, "/financial_instruments", "vgs.financial_instruments.create"},
+		{http.MethodGet, "/financial_instruments", "vgs.financial_instruments.list"},
+		{http.MethodGet, "/financial_instruments/FI1", "vgs.financial_instruments.get"},
+		{http.MethodPatch, "/gateways/GW1", "vgs.gateways.update"},
+		{http.MethodDelete, "/rules/RL1", "vgs.rules.delete"},
+		{http.MethodPost, "/transfers/TR1/capture", "vgs.transfers.capture"},
+		{http.MethodPost, "/transfers/TR1/refunds", "vgs.transfers.refunds"},
+		{http.MethodGet, "/", "vgs.get"},
+	}
+	for _, testCase := range testCases {
+		req := httptest.NewRequest(testCase.method, testCase.path, nil)
+		assert.Equal(t, testCase.expected, OperationName(nil, req), testCase.path)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+	assert.Equal(t, "custom", OperationName(NewJsonRequest(http.MethodPost, "/transfers", nil, WithOperation("custom")), req))
+	assert.Equal(t, "vgs.vault.post", OperationName(&Request{unauthenticated: true}, req))
+}
+
+func TestRouteTemplate(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "/transfers/{id}/capture", RouteTemplate("/transfers/TR1/capture"))
+	assert.Equal(t, "/gateways", RouteTemplate("/gateways/"))
+	assert.Equal(t, "/", RouteTemplate(""))
+}
+
+func TestRoute(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest(http.MethodPost, "/transfers/TR1/capture", nil)
+	assert.Equal(t, "/transfers/{id}/capture", Route(nil, req))
+	assert.Equal(t, "/transfers/{id}/capture", Route(NewJsonRequest(http.MethodPost, "/transfers", nil, WithOperation("custom")), req))
+
+	req = httptest.NewRequest(http.MethodPost, "/cards/4111111111111111", nil)
+	vault := &Request{unauthenticated: true}
+	assert.Equal(t, "vault", Route(vault, req))
+	vault.operation = "tokenize"
+	assert.Equal(t, "tokenize", Route(vault, req))
+	req = req.WithContext(context.WithValue(req.Context(), requestKey{}, vault))
+	assert.Equal(t, "tokenize", Route(nil, req))
+	assert.Equal(t, "tokenize", OperationName(nil, req))
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "", ErrorClass(nil))
+	assert.Equal(t, "not_found", ErrorClass(&APIError{StatusCode: http.StatusNotFound}))
+	assert.Equal(t, "rate_limited", ErrorClass(fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusTooManyRequests})))
+	assert.Equal(t, "auth_invalid_client", ErrorClass(&AuthError{StatusCode: http.StatusUnauthorized, ErrorCode: "invalid_client"}))
+	assert.Equal(t, "timeout", ErrorClass(context.DeadlineExceeded))
+	assert.Equal(t, "other", ErrorClass(fmt.Errorf("dummy")))
+}
+
+func TestHooks(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	}))
+	defer server.Close()
+
+	c, err := NewClient(&Options{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		VaultId:      "tntabc",
+		RouteId:      "route",
+		HTTPClient:   &flakyHTTPClient{failures: 1, next: &mockHTTPClient{newMockHandler(http.StatusOK, `{"data": []}`, nil)}},
+		RetryPolicy:  &RetryPolicy{MaxAttempts: 2, RetryNetworkErrors: true},
+	})
+	assert.Nil(t, err)
+	c.Options.Authenticator.(*OAuthAuthenticator).OAuthURL = server.URL
+	var retries []int
+	var fetches []TokenFetchEvent
+	c.AddHooks(Hooks{
+		OnRetry: func(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error) {
+			assert.Nil(t, resp)
+			assert.NotNil(t, err)
+			retries = append(retries, attempt)
+		},
+		OnTokenFetch: func(ctx context.Context, event TokenFetchEvent) {
+			fetches = append(fetches, event)
+		},
+	})
+	_, err = c.GetGateways()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, retries)
+	if assert.Len(t, fetches, 1) {
+		assert.Equal(t, "client_credentials", fetches[0].GrantType)
+		assert.Nil(t, fetches[0].Err)
+	}
+}
+
+func TestAddHooksSharedAuthenticator(t *testing.T) {
+	t.Parallel()
+	shared := NewOAuthAuthenticator("client", "secret")
+	options := func() *Options {
+		return &Options{ClientID: "client", ClientSecret: "secret", VaultId: "tntabc", RouteId: "route", Authenticator: shared}
+	}
+	for i := 0; i < 2; i++ {
+		c, err := NewClient(options())
+		assert.Nil(t, err)
+		c.AddHooks(Hooks{OnTokenFetch: func(context.Context, TokenFetchEvent) {}})
+		assert.Len(t, c.Options.Hooks, 1)
+	}
+	assert.Empty(t, shared.Hooks)
+
+	own := options()
+	own.Authenticator = nil
+	c, err := NewClient(own)
+	assert.Nil(t, err)
+	c.AddHooks(Hooks{OnTokenFetch: func(context.Context, TokenFetchEvent) {}})
+	assert.Len(t, c.Options.Authenticator.(*OAuthAuthenticator).Hooks, 1)
+}
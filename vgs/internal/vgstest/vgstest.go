@@ -0,0 +1,45 @@
+// Package vgstest provides the test client shared by the instrumentation
+// packages.
+package vgstest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ula/vgs-client/vgs"
+)
+
+// NewClient returns a client whose API and vault calls are served by
+// handler. Its token requests get tokenStatus, with a token for
+// http.StatusOK and an invalid_client error otherwise.
+func NewClient(t testing.TB, tokenStatus int, handler http.HandlerFunc) *vgs.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.WriteHeader(tokenStatus)
+			if tokenStatus == http.StatusOK {
+				w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+			} else {
+				w.Write([]byte(`{"error": "invalid_client"}`))
+			}
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	baseUrl, _ := url.Parse(server.URL)
+	c, err := vgs.NewClient(&vgs.Options{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		VaultId:      "tntabc",
+		RouteId:      "route",
+		PaymentURL:   baseUrl,
+		VaultURL:     baseUrl,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Options.Authenticator.(*vgs.OAuthAuthenticator).OAuthURL = server.URL + "/token"
+	return c
+}
@@ -0,0 +1,80 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel matches the levels of log/slog.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = -4
+	LogLevelInfo  LogLevel = 0
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch {
+	case l < LogLevelInfo:
+		return "DEBUG"
+	case l < LogLevelWarn:
+		return "INFO"
+	case l < LogLevelError:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger receives the log records of the client. Fields alternate keys and
+// values, as in log/slog.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, fields ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(context.Context, LogLevel, string, ...interface{}) {}
+
+// NopLogger returns a Logger discarding every record, the default.
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+}
+
+// NewStdLogger returns a Logger writing key=value lines to logger, or to the
+// standard logger when nil.
+func NewStdLogger(logger *log.Logger) Logger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &stdLogger{logger: logger}
+}
+
+func (l *stdLogger) Log(_ context.Context, level LogLevel, msg string, fields ...interface{}) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "level=%s msg=%q", level, msg)
+	for i := 0; i < len(fields); i += 2 {
+		if i+1 < len(fields) {
+			fmt.Fprintf(&b, " %v=%v", fields[i], fields[i+1])
+		} else {
+			fmt.Fprintf(&b, " !BADKEY=%v", fields[i])
+		}
+	}
+	l.logger.Print(b.String())
+}
+
+// log sends a record to Options.Logger unless it is below Options.LogLevel.
+func (o *Options) log(ctx context.Context, level LogLevel, msg string, fields ...interface{}) {
+	if o.Logger == nil || level < o.LogLevel {
+		return
+	}
+	o.Logger.Log(ctx, level, msg, fields...)
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+
+package vgs
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger writing to logger, or to slog.Default when
+// nil.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+func (l *slogLogger) Log(ctx context.Context, level LogLevel, msg string, fields ...interface{}) {
+	l.logger.Log(ctx, slog.Level(level), msg, fields...)
+}
@@ -0,0 +1,25 @@
+//go:build go1.21
+
+package vgs
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": []}`, map[string]string{VGSRequestId: "req-1"}))
+	c.Options.Logger = NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	c.Options.LogLevel = LogLevelDebug
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), `"level":"DEBUG"`)
+	assert.Contains(t, buf.String(), `"vgs_request_id":"req-1"`)
+	assert.Contains(t, buf.String(), `"status":200`)
+}
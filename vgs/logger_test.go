@@ -0,0 +1,123 @@
+package vgs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logRecord struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) Log(_ context.Context, level LogLevel, msg string, fields ...interface{}) {
+	record := logRecord{level: level, msg: msg, fields: map[string]interface{}{}}
+	for i := 0; i+1 < len(fields); i += 2 {
+		record.fields[fields[i].(string)] = fields[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+func TestLoggerFields(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": []}`, map[string]string{VGSRequestId: "req-1", TraceId: "trace-1"}))
+	c.Options.Logger = logger
+	c.Options.LogLevel = LogLevelDebug
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+
+	if assert.Len(t, logger.records, 1) {
+		record := logger.records[0]
+		assert.Equal(t, LogLevelDebug, record.level)
+		assert.Equal(t, "request completed", record.msg)
+		assert.Equal(t, http.MethodGet, record.fields["method"])
+		assert.Equal(t, "/gateways", record.fields["path"])
+		assert.Equal(t, http.StatusOK, record.fields["status"])
+		assert.Equal(t, "req-1", record.fields["vgs_request_id"])
+		assert.Equal(t, "trace-1", record.fields["trace_id"])
+		assert.Contains(t, record.fields, "latency")
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	c := NewMockClientWithHandler(newMockHandler(http.StatusNotFound, `{"errors": [{"detail": "not found"}]}`, nil))
+	c.Options.Logger = logger
+	_, err := c.GetGateways()
+	assert.NotNil(t, err)
+	_, err = c.GetGateway("missing")
+	assert.NotNil(t, err)
+	assert.Len(t, logger.records, 2)
+	for _, record := range logger.records {
+		assert.Equal(t, LogLevelWarn, record.level)
+		assert.Equal(t, http.StatusNotFound, record.fields["status"])
+	}
+
+	c.Options.LogLevel = LogLevelError
+	_, err = c.GetGateways()
+	assert.NotNil(t, err)
+	assert.Len(t, logger.records, 2)
+}
+
+func TestLoggerRetries(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	c, _ := NewMockClient(&flakyHTTPClient{failures: 1, next: &mockHTTPClient{newMockHandler(http.StatusOK, `{"data": []}`, nil)}})
+	c.Options.Logger = logger
+	c.Options.RetryPolicy = &RetryPolicy{MaxAttempts: 2, RetryNetworkErrors: true}
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+	if assert.Len(t, logger.records, 1) {
+		assert.Equal(t, "retrying request", logger.records[0].msg)
+		assert.Equal(t, 1, logger.records[0].fields["attempt"])
+	}
+}
+
+type failingBody struct{}
+
+func (failingBody) Read([]byte) (int, error) { return 0, errors.New("connection reset") }
+func (failingBody) Close() error             { return nil }
+
+type failingBodyHTTPClient struct{}
+
+func (failingBodyHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: failingBody{}, Request: req}, nil
+}
+
+func TestReadBodyError(t *testing.T) {
+	t.Parallel()
+	logger := &recordingLogger{}
+	c, _ := NewMockClient(failingBodyHTTPClient{})
+	c.Options.Logger = logger
+	_, err := c.GetGateways()
+	assert.ErrorContains(t, err, "unable to read response body")
+	if assert.Len(t, logger.records, 1) {
+		assert.Equal(t, LogLevelError, logger.records[0].level)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0))
+	logger.Log(context.Background(), LogLevelWarn, "request rejected", "status", 404, "path", "/gateways")
+	assert.Equal(t, "level=WARN msg=\"request rejected\" status=404 path=/gateways\n", buf.String())
+	NopLogger().Log(context.Background(), LogLevelError, "dropped")
+}
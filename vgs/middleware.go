@@ -0,0 +1,93 @@
+package vgs
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Handler sends req and returns its response, with an APIError for non-2xx
+// statuses. request is the call req was built from, or nil for requests
+// passed to Client.Do. The context of the call is req.Context().
+type Handler func(request *Request, req *http.Request) (*Response, error)
+
+// Middleware wraps a Handler to add behavior around every call of the
+// client, e.g. headers, request signing, metrics or tracing.
+type Middleware func(next Handler) Handler
+
+// chain wraps handler with middlewares, the first middleware is the
+// outermost.
+func chain(handler Handler, middlewares []Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// HeaderMiddleware sets header on every request.
+func HeaderMiddleware(header http.Header) Middleware {
+	return func(next Handler) Handler {
+		return func(request *Request, req *http.Request) (*Response, error) {
+			for key, values := range header {
+				req.Header[key] = values
+			}
+			return next(request, req)
+		}
+	}
+}
+
+// middlewares returns Options.Middlewares followed by the authentication,
+// unless Options.DisableAuthentication is set.
+func (c *Client) middlewares() []Middleware {
+	middlewares := c.Options.Middlewares
+	if c.Options.DisableAuthentication {
+		return middlewares
+	}
+	return append(middlewares[:len(middlewares):len(middlewares)], c.authenticationMiddleware)
+}
+
+// authenticationMiddleware authenticates with the current
+// Options.Authenticator, so replacing the authenticator of a client takes
+// effect on the next call.
+func (c *Client) authenticationMiddleware(next Handler) Handler {
+	return func(request *Request, req *http.Request) (*Response, error) {
+		return AuthenticationMiddleware(c.Options.Authenticator)(next)(request, req)
+	}
+}
+
+// AuthenticationMiddleware authenticates requests with authenticator. A
+// request rejected because of an expired token is replayed once with a new
+// token when the authenticator implements TokenInvalidator. Clients
+// authenticate with it after Options.Middlewares, add it to the middlewares
+// to authenticate at another position with Options.DisableAuthentication.
+func AuthenticationMiddleware(authenticator Authenticator) Middleware {
+	return func(next Handler) Handler {
+		return func(request *Request, req *http.Request) (*Response, error) {
+			if authenticator == nil || (request != nil && request.unauthenticated) {
+				return next(request, req)
+			}
+			if err := authenticator.SetAuthentication(req); err != nil {
+				return nil, err
+			}
+			response, err := next(request, req)
+			if response == nil || !isExpiredTokenResponse(response) {
+				return response, err
+			}
+			invalidator, ok := authenticator.(TokenInvalidator)
+			if !ok || (req.Body != nil && req.GetBody == nil) {
+				return response, err
+			}
+			invalidator.InvalidateToken(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+			if err := authenticator.SetAuthentication(req); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+			return next(request, req)
+		}
+	}
+}
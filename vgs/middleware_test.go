@@ -0,0 +1,146 @@
+package vgs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	t.Parallel()
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(request *Request, req *http.Request) (*Response, error) {
+				order = append(order, name+" before")
+				response, err := next(request, req)
+				order = append(order, name+" after")
+				return response, err
+			}
+		}
+	}
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": []}`, nil))
+	c.Options.Middlewares = append([]Middleware{trace("outer"), trace("inner")}, c.Options.Middlewares...)
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, order)
+}
+
+func TestMiddlewareAccess(t *testing.T) {
+	t.Parallel()
+	var seen *Response
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test", r.Header.Get("Authentication"))
+		assert.Equal(t, "signed", r.Header.Get("X-Signature"))
+		w.Header().Set(VGSRequestId, "req-1")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	c.Options.Middlewares = append(c.Options.Middlewares, func(next Handler) Handler {
+		return func(request *Request, req *http.Request) (*Response, error) {
+			assert.Equal(t, "/gateways/missing", request.Uri)
+			req.Header.Set("X-Signature", "signed")
+			response, err := next(request, req)
+			seen = response
+			assert.True(t, IsNotFound(err))
+			return response, err
+		}
+	})
+	_, err := c.GetGateway("missing")
+	assert.True(t, IsNotFound(err))
+	if assert.NotNil(t, seen) {
+		assert.Equal(t, "req-1", seen.VGSRequestId)
+	}
+}
+
+func TestReplaceAuthenticationMiddleware(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authentication"))
+		assert.Equal(t, "Bearer custom", r.Header.Get("Authorization"))
+		assert.Equal(t, "value", r.Header.Get("X-Custom"))
+		w.Write([]byte(`{"data": []}`))
+	})
+	c.Options.DisableAuthentication = true
+	c.Options.Middlewares = []Middleware{HeaderMiddleware(http.Header{
+		"Authorization": {"Bearer custom"},
+		"X-Custom":      {"value"},
+	})}
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+
+	req, _ := c.NewRequest(NewJsonRequest(http.MethodGet, "/gateways", nil))
+	_, err = c.Do(req, nil)
+	assert.Nil(t, err)
+}
+
+func TestMiddlewaresAuthenticated(t *testing.T) {
+	t.Parallel()
+	var logged []string
+	logging := func(next Handler) Handler {
+		return func(request *Request, req *http.Request) (*Response, error) {
+			logged = append(logged, req.URL.Path)
+			return next(request, req)
+		}
+	}
+	c, err := NewClient(&Options{
+		ClientID:      "test-client",
+		ClientSecret:  "test-secret",
+		VaultId:       "test-vault",
+		RouteId:       "test-route",
+		Authenticator: &MockAuthenticator{},
+		Middlewares:   []Middleware{logging},
+		HTTPClient: &mockHTTPClient{mockHandler: func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer test", r.Header.Get("Authentication"))
+			w.Write([]byte(`{"data": []}`))
+		}},
+	})
+	assert.Nil(t, err)
+	_, err = c.GetGateways()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/gateways"}, logged)
+}
+
+type headerAuthenticator string
+
+func (h headerAuthenticator) Authenticate() (*OAuthToken, error) {
+	return &OAuthToken{AccessToken: string(h), ExpiresIn: 3600, CreatedAt: time.Now()}, nil
+}
+
+func (h headerAuthenticator) SetAuthentication(r *http.Request) error {
+	r.Header.Set("Authorization", "Bearer "+string(h))
+	return nil
+}
+
+func TestAuthenticationMiddlewareCurrentAuthenticator(t *testing.T) {
+	t.Parallel()
+	var authorization string
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{"data": []}`))
+	})
+	c.Options.Authenticator = headerAuthenticator("first")
+
+	req, err := c.NewRequest(NewJsonRequest(http.MethodGet, "/gateways", nil))
+	assert.Nil(t, err)
+	assert.Empty(t, req.Header.Get("Authorization"))
+	_, err = c.Do(req, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer first", authorization)
+
+	c.Options.Authenticator = headerAuthenticator("second")
+	_, err = c.GetGateways()
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer second", authorization)
+}
+
+func TestVaultClientSkipsAuthentication(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authentication"))
+		w.Write([]byte(`{}`))
+	})
+	_, err := c.VaultClient().Post("/post", map[string]string{}, nil)
+	assert.Nil(t, err)
+}
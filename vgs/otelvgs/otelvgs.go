@@ -0,0 +1,224 @@
+// Package otelvgs instruments a vgs.Client with OpenTelemetry: a client span
+// per API call, W3C trace context propagation and request, retry and token
+// metrics. It is a module of its own, so only its users depend on
+// OpenTelemetry.
+package otelvgs
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ula/vgs-client/vgs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ula/vgs-client/vgs/otelvgs"
+
+// Attribute keys of spans and metrics.
+const (
+	OperationKey    = attribute.Key("vgs.operation")
+	RequestIdKey    = attribute.Key("vgs.request_id")
+	TraceIdKey      = attribute.Key("vgs.trace_id")
+	GrantTypeKey    = attribute.Key("vgs.grant_type")
+	MethodKey       = attribute.Key("http.request.method")
+	StatusCodeKey   = attribute.Key("http.response.status_code")
+	RouteKey        = attribute.Key("url.template")
+	ServerAddrKey   = attribute.Key("server.address")
+	ErrorTypeKey    = attribute.Key("error.type")
+	RetryAttemptKey = attribute.Key("vgs.retry.attempt")
+)
+
+type config struct {
+	tracerProvider   trace.TracerProvider
+	meterProvider    metric.MeterProvider
+	propagator       propagation.TextMapPropagator
+	vaultPropagation bool
+}
+
+type Option func(*config)
+
+// WithTracerProvider defaults to the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider defaults to the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator defaults to W3C trace context.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// WithVaultPropagation injects the trace context into VaultClient calls as
+// well. They are passed on to the upstream of the inbound route, so it is
+// not injected by default.
+func WithVaultPropagation() Option {
+	return func(c *config) {
+		c.vaultPropagation = true
+	}
+}
+
+// Instrumentation holds the tracer and metric instruments.
+type Instrumentation struct {
+	tracer           trace.Tracer
+	propagator       propagation.TextMapPropagator
+	vaultPropagation bool
+
+	requests      metric.Int64Counter
+	duration      metric.Float64Histogram
+	retries       metric.Int64Counter
+	tokenFetches  metric.Int64Counter
+	tokenDuration metric.Float64Histogram
+}
+
+// New creates the instrumentation, see Instrument to add it to a client.
+func New(options ...Option) (*Instrumentation, error) {
+	c := &config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     propagation.TraceContext{},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	meter := c.meterProvider.Meter(instrumentationName)
+	i := &Instrumentation{
+		tracer:           c.tracerProvider.Tracer(instrumentationName),
+		propagator:       c.propagator,
+		vaultPropagation: c.vaultPropagation,
+	}
+	var err error
+	if i.requests, err = meter.Int64Counter("vgs.client.requests",
+		metric.WithDescription("API calls by operation, status and error class.")); err != nil {
+		return nil, err
+	}
+	if i.duration, err = meter.Float64Histogram("vgs.client.request.duration",
+		metric.WithDescription("Duration of API calls, retries included."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if i.retries, err = meter.Int64Counter("vgs.client.retries",
+		metric.WithDescription("Retried attempts of API calls.")); err != nil {
+		return nil, err
+	}
+	if i.tokenFetches, err = meter.Int64Counter("vgs.client.token.fetches",
+		metric.WithDescription("Token requests by grant type and error class.")); err != nil {
+		return nil, err
+	}
+	if i.tokenDuration, err = meter.Float64Histogram("vgs.client.token.duration",
+		metric.WithDescription("Duration of token requests."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// Instrument adds tracing and metrics to c. The middleware is the outermost
+// one, so spans include authentication and retries. Token requests of an
+// Options.Authenticator are only observed when Hooks are added to it, see
+// vgs.Client.AddHooks.
+func Instrument(c *vgs.Client, options ...Option) error {
+	i, err := New(options...)
+	if err != nil {
+		return err
+	}
+	c.Options.Middlewares = append([]vgs.Middleware{i.Middleware()}, c.Options.Middlewares...)
+	c.AddHooks(i.Hooks())
+	return nil
+}
+
+// Middleware creates a client span per call, named by vgs.OperationName,
+// and records the request metrics.
+func (i *Instrumentation) Middleware() vgs.Middleware {
+	return func(next vgs.Handler) vgs.Handler {
+		return func(request *vgs.Request, req *http.Request) (*vgs.Response, error) {
+			operation := vgs.OperationName(request, req)
+			attrs := []attribute.KeyValue{
+				OperationKey.String(operation),
+				MethodKey.String(req.Method),
+				RouteKey.String(vgs.Route(request, req)),
+				ServerAddrKey.String(req.URL.Hostname()),
+			}
+			ctx, span := i.tracer.Start(req.Context(), operation,
+				trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			defer span.End()
+			req = req.WithContext(ctx)
+			if i.vaultPropagation || !vgs.VaultCall(request, req) {
+				i.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+			}
+
+			start := time.Now()
+			response, err := next(request, req)
+			metricAttrs := []attribute.KeyValue{OperationKey.String(operation), MethodKey.String(req.Method)}
+			if response != nil {
+				status := StatusCodeKey.Int(response.StatusCode)
+				span.SetAttributes(status)
+				metricAttrs = append(metricAttrs, status)
+				if response.VGSRequestId != "" {
+					span.SetAttributes(RequestIdKey.String(response.VGSRequestId))
+				}
+				if response.TraceId != "" {
+					span.SetAttributes(TraceIdKey.String(response.TraceId))
+				}
+			}
+			if err != nil {
+				errorType := ErrorTypeKey.String(vgs.ErrorClass(err))
+				span.SetAttributes(errorType)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				metricAttrs = append(metricAttrs, errorType)
+			}
+			// record metrics even when the call context was canceled
+			metricCtx := context.Background()
+			i.requests.Add(metricCtx, 1, metric.WithAttributes(metricAttrs...))
+			i.duration.Record(metricCtx, time.Since(start).Seconds(), metric.WithAttributes(metricAttrs...))
+			return response, err
+		}
+	}
+}
+
+// Hooks records retries as span events and token requests as spans.
+func (i *Instrumentation) Hooks() vgs.Hooks {
+	return vgs.Hooks{
+		OnRetry: func(ctx context.Context, req *http.Request, attempt int, resp *http.Response, err error) {
+			attrs := []attribute.KeyValue{RetryAttemptKey.Int(attempt)}
+			if resp != nil {
+				attrs = append(attrs, StatusCodeKey.Int(resp.StatusCode))
+			}
+			if err != nil {
+				attrs = append(attrs, ErrorTypeKey.String(vgs.ErrorClass(err)))
+			}
+			trace.SpanFromContext(ctx).AddEvent("retry", trace.WithAttributes(attrs...))
+			i.retries.Add(context.Background(), 1, metric.WithAttributes(
+				OperationKey.String(vgs.OperationName(nil, req)), MethodKey.String(req.Method)))
+		},
+		OnTokenFetch: func(ctx context.Context, event vgs.TokenFetchEvent) {
+			attrs := []attribute.KeyValue{GrantTypeKey.String(event.GrantType)}
+			if event.Err != nil {
+				attrs = append(attrs, ErrorTypeKey.String(vgs.ErrorClass(event.Err)))
+			}
+			_, span := i.tracer.Start(ctx, "vgs.auth.token", trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithTimestamp(event.Start), trace.WithAttributes(attrs...))
+			if event.Err != nil {
+				span.RecordError(event.Err)
+				span.SetStatus(codes.Error, event.Err.Error())
+			}
+			span.End(trace.WithTimestamp(event.Start.Add(event.Duration)))
+			i.tokenFetches.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+			i.tokenDuration.Record(context.Background(), event.Duration.Seconds(), metric.WithAttributes(attrs...))
+		},
+	}
+}
@@ -0,0 +1,174 @@
+package otelvgs
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ula/vgs-client/vgs"
+	"github.com/ula/vgs-client/vgs/internal/vgstest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newInstrumentedClient(t *testing.T, handler http.HandlerFunc, options ...Option) (*vgs.Client, *tracetest.SpanRecorder, sdkmetric.Reader) {
+	c := vgstest.NewClient(t, http.StatusOK, handler)
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	err := Instrument(c, append([]Option{
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	}, options...)...)
+	assert.Nil(t, err)
+	return c, recorder, reader
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	assert.Nil(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func TestInstrumentSpans(t *testing.T) {
+	t.Parallel()
+	c, recorder, reader := newInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("traceparent"), "00-"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Header().Set(vgs.VGSRequestId, "req-1")
+		w.Header().Set(vgs.TraceId, "trace-1")
+		w.Write([]byte(`{"data": {"id": "FI1"}}`))
+	})
+	_, err := c.CreatePaymentCard(&vgs.CreatePaymentCardRequest{})
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 2) {
+		return
+	}
+	token, call := spans[0], spans[1]
+	assert.Equal(t, "vgs.auth.token", token.Name())
+	assert.Equal(t, call.SpanContext().SpanID(), token.Parent().SpanID())
+	assert.Equal(t, "vgs.financial_instruments.create", call.Name())
+	assert.Equal(t, "req-1", spanAttribute(call, RequestIdKey).AsString())
+	assert.Equal(t, "trace-1", spanAttribute(call, TraceIdKey).AsString())
+	assert.Equal(t, int64(200), spanAttribute(call, StatusCodeKey).AsInt64())
+
+	metrics := collect(t, reader)
+	requests := metrics["vgs.client.requests"].(metricdata.Sum[int64])
+	if assert.Len(t, requests.DataPoints, 1) {
+		assert.Equal(t, int64(1), requests.DataPoints[0].Value)
+	}
+	fetches := metrics["vgs.client.token.fetches"].(metricdata.Sum[int64])
+	if assert.Len(t, fetches.DataPoints, 1) {
+		grantType, _ := fetches.DataPoints[0].Attributes.Value(GrantTypeKey)
+		assert.Equal(t, "client_credentials", grantType.AsString())
+	}
+	assert.Contains(t, metrics, "vgs.client.request.duration")
+}
+
+func TestInstrumentErrors(t *testing.T) {
+	t.Parallel()
+	c, recorder, reader := newInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors": [{"detail": "not found"}]}`))
+	})
+	_, err := c.GetTransfer("missing")
+	assert.True(t, vgs.IsNotFound(err))
+
+	spans := recorder.Ended()
+	call := spans[len(spans)-1]
+	assert.Equal(t, "vgs.transfers.get", call.Name())
+	assert.Equal(t, codes.Error, call.Status().Code)
+	assert.Equal(t, "not_found", spanAttribute(call, ErrorTypeKey).AsString())
+
+	requests := collect(t, reader)["vgs.client.requests"].(metricdata.Sum[int64])
+	if assert.Len(t, requests.DataPoints, 1) {
+		errorType, _ := requests.DataPoints[0].Attributes.Value(ErrorTypeKey)
+		assert.Equal(t, "not_found", errorType.AsString())
+	}
+}
+
+func TestInstrumentRetries(t *testing.T) {
+	t.Parallel()
+	attempts := 0
+	c, recorder, reader := newInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	})
+	c.Options.RetryPolicy = &vgs.RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusServiceUnavailable}}
+	_, err := c.GetGateways()
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	call := spans[len(spans)-1]
+	if assert.Len(t, call.Events(), 1) {
+		assert.Equal(t, "retry", call.Events()[0].Name)
+	}
+	retries := collect(t, reader)["vgs.client.retries"].(metricdata.Sum[int64])
+	if assert.Len(t, retries.DataPoints, 1) {
+		assert.Equal(t, int64(1), retries.DataPoints[0].Value)
+	}
+}
+
+func TestInstrumentVaultRoute(t *testing.T) {
+	t.Parallel()
+	attempts := 0
+	c, recorder, reader := newInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("traceparent"))
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	c.Options.RetryPolicy = &vgs.RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusServiceUnavailable}}
+	_, err := c.VaultClient().Put("/cards/4111111111111111", map[string]string{}, nil)
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "vgs.vault.put", spans[0].Name())
+		assert.Equal(t, "vault", spanAttribute(spans[0], RouteKey).AsString())
+	}
+	retries := collect(t, reader)["vgs.client.retries"].(metricdata.Sum[int64])
+	if assert.Len(t, retries.DataPoints, 1) {
+		operation, _ := retries.DataPoints[0].Attributes.Value(OperationKey)
+		assert.Equal(t, "vgs.vault.put", operation.AsString())
+	}
+}
+
+func TestInstrumentVaultPropagation(t *testing.T) {
+	t.Parallel()
+	c, _, _ := newInstrumentedClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("traceparent"), "00-"))
+		w.Write([]byte(`{}`))
+	}, WithVaultPropagation())
+	_, err := c.VaultClient().Post("/post", map[string]string{}, nil)
+	assert.Nil(t, err)
+}
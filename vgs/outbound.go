@@ -0,0 +1,99 @@
+package vgs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// OutboundProxyPort is the port of the TLS endpoint of the outbound proxy.
+var OutboundProxyPort = 8443
+
+// GetOutboundProxyUrl returns the forward proxy url of the vault, including
+// the proxy credentials.
+func (o *Options) GetOutboundProxyUrl() (*url.URL, error) {
+	if o.VaultId == "" {
+		return nil, errors.New("VaultId is required")
+	}
+	if o.ProxyUsername == "" || o.ProxyPassword == "" {
+		return nil, errors.New("ProxyUsername and ProxyPassword are required")
+	}
+	config, err := o.environment()
+	if err != nil {
+		return nil, err
+	}
+	host := config.OutboundProxyHost
+	if !hasPort(host) {
+		host = net.JoinHostPort(host, strconv.Itoa(OutboundProxyPort))
+	}
+	u, err := o.url(config, host)
+	if err != nil {
+		return nil, err
+	}
+	u.User = url.UserPassword(o.ProxyUsername, o.ProxyPassword)
+	return u, nil
+}
+
+// errProxyCACertRequired is returned when the outbound proxy is used without
+// the VGS CA certificate, the system roots do not trust the connections the
+// proxy re-signs.
+var errProxyCACertRequired = errors.New("proxy CA certificate or proxy CA certificate file is required")
+
+// proxyRootCAs returns the system roots with the VGS CA certificate added.
+func (o *Options) proxyRootCAs() (*x509.CertPool, error) {
+	pem := o.ProxyCACert
+	if len(pem) == 0 {
+		if o.ProxyCACertFile == "" {
+			return nil, errProxyCACertRequired
+		}
+		data, err := os.ReadFile(o.ProxyCACertFile)
+		if err != nil {
+			return nil, err
+		}
+		pem = data
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificate found in the proxy CA certificate")
+	}
+	return pool, nil
+}
+
+// NewOutboundTransport returns a transport sending every request through
+// the vault outbound proxy, so aliases in the requests are revealed before
+// they reach the third party.
+func NewOutboundTransport(options *Options) (*http.Transport, error) {
+	proxyUrl, err := options.GetOutboundProxyUrl()
+	if err != nil {
+		return nil, err
+	}
+	rootCAs, err := options.proxyRootCAs()
+	if err != nil {
+		return nil, err
+	}
+	transport := cloneDefaultTransport()
+	transport.Proxy = http.ProxyURL(proxyUrl)
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	return transport, nil
+}
+
+// NewOutboundHTTPClient returns an http client for calling third parties
+// through the vault outbound proxy.
+func NewOutboundHTTPClient(options *Options) (*http.Client, error) {
+	transport, err := NewOutboundTransport(options)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,149 @@
+package vgs
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+var ErrNoMorePages = errors.New("no more pages")
+
+// PageOption sets the query of the first page requested by a Paginator, the
+// next links carry it to the following pages.
+type PageOption = RequestOption
+
+// pageQuery applies set to the query of the request.
+func pageQuery(set func(url.Values)) PageOption {
+	return func(r *Request) {
+		if r.Values == nil {
+			r.Values = url.Values{}
+		}
+		set(r.Values)
+	}
+}
+
+func WithPageSize(size int) PageOption {
+	return pageQuery(func(v url.Values) {
+		v.Set("page[size]", strconv.Itoa(size))
+	})
+}
+
+func WithPageCursor(cursor string) PageOption {
+	return pageQuery(func(v url.Values) {
+		v.Set("page[cursor]", cursor)
+	})
+}
+
+func WithPageQuery(query url.Values) PageOption {
+	return pageQuery(func(v url.Values) {
+		for key, values := range query {
+			v[key] = append([]string(nil), values...)
+		}
+	})
+}
+
+type Page[T any] struct {
+	Data  []T           `json:"data"`
+	Links ResponseLinks `json:"links"`
+	Meta  ResponseMeta  `json:"meta"`
+}
+
+// Paginator walks a list endpoint page by page following ResponseLinks.Next.
+type Paginator[T any] struct {
+	client  *Client
+	next    string
+	options []RequestOption
+	fetched bool
+	done    bool
+	meta    ResponseMeta
+}
+
+// NewPaginator returns a Paginator starting at uri. The options apply to
+// every page request, except the query they set which only applies to the
+// first page.
+func NewPaginator[T any](c *Client, uri string, options ...RequestOption) *Paginator[T] {
+	return &Paginator[T]{client: c, next: uri, options: options}
+}
+
+func (p *Paginator[T]) HasNext() bool {
+	return !p.done
+}
+
+// Meta returns the meta of the last fetched page.
+func (p *Paginator[T]) Meta() ResponseMeta {
+	return p.meta
+}
+
+// Next fetches the next page and returns its items, ErrNoMorePages is
+// returned once the last page was consumed.
+func (p *Paginator[T]) Next(ctx context.Context) ([]T, error) {
+	if p.done {
+		return nil, ErrNoMorePages
+	}
+	options := p.options
+	if p.fetched {
+		// the next link carries the query of the first page
+		options = append(options[:len(options):len(options)], func(r *Request) {
+			r.Values = nil
+		})
+	}
+	page := &Page[T]{}
+	if _, err := p.client.GetContext(ctx, p.next, page, options...); err != nil {
+		return nil, err
+	}
+	p.fetched = true
+	p.meta = page.Meta
+	next, err := p.nextUri(page.Links.Next)
+	if err != nil {
+		return nil, err
+	}
+	if next == "" || next == p.next || len(page.Data) == 0 {
+		p.done = true
+	}
+	p.next = next
+	return page.Data, nil
+}
+
+// All fetches the remaining pages and returns their items.
+func (p *Paginator[T]) All(ctx context.Context) ([]T, error) {
+	var items []T
+	for p.HasNext() {
+		page, err := p.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
+
+// nextUri keeps only the path and query of the next link so the credentials
+// are never sent to a host other than the configured one.
+func (p *Paginator[T]) nextUri(link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return u.RequestURI(), nil
+}
+
+func (c *Client) PaginateFinancialInstruments(filter *FinancialInstrumentsFilter, options ...RequestOption) *Paginator[FinancialInstrumentData] {
+	options = append([]RequestOption{WithPageQuery(filter.Values())}, options...)
+	return NewPaginator[FinancialInstrumentData](c, "/financial_instruments", options...)
+}
+
+func (c *Client) PaginateGateways(options ...RequestOption) *Paginator[Gateway] {
+	return NewPaginator[Gateway](c, "/gateways", options...)
+}
+
+func (c *Client) PaginateTransfers(options ...RequestOption) *Paginator[Transfer] {
+	return NewPaginator[Transfer](c, "/transfers", options...)
+}
+
+func (c *Client) PaginateRules(options ...RequestOption) *Paginator[Rule] {
+	return NewPaginator[Rule](c, "/rules", options...)
+}
@@ -0,0 +1,97 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPagedHandler(t *testing.T, pages int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/financial_instruments", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("page[size]"))
+		assert.Equal(t, "SA1", r.URL.Query().Get("sub_account_id"))
+		page := 1
+		fmt.Sscan(r.URL.Query().Get("page[number]"), &page)
+		next := ""
+		if page < pages {
+			next = fmt.Sprintf("https://elsewhere.example.com/financial_instruments?page[size]=2&page[number]=%d&sub_account_id=SA1", page+1)
+		}
+		fmt.Fprintf(w, `{"data": [{"id": "FI%d-1"}, {"id": "FI%d-2"}], "links": {"next": "%s"}, "meta": {"total_elements": %d, "total_pages": %d}}`, page, page, next, pages*2, pages)
+	}
+}
+
+func TestPaginatorNext(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-vault-test-route.sandbox.verygoodproxy.com", r.URL.Host)
+		newPagedHandler(t, 3)(w, r)
+	})
+	paginator := c.PaginateFinancialInstruments(&FinancialInstrumentsFilter{SubAccountID: "SA1"}, WithPageSize(2))
+	pages := 0
+	for paginator.HasNext() {
+		items, err := paginator.Next(context.Background())
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+		pages++
+	}
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, 6, paginator.Meta().TotalElements)
+
+	_, err := paginator.Next(context.Background())
+	assert.ErrorIs(t, err, ErrNoMorePages)
+}
+
+func TestPaginatorAll(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newPagedHandler(t, 2))
+	items, err := c.PaginateFinancialInstruments(&FinancialInstrumentsFilter{SubAccountID: "SA1"}, WithPageSize(2)).All(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, items, 4)
+	assert.Equal(t, "FI2-2", items[3].ID)
+}
+
+func TestPaginatorError(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusBadRequest, `{"error": "error", "error_description": "dummy error"}`, nil))
+	items, err := c.PaginateGateways().All(context.Background())
+	assert.ErrorContains(t, err, "dummy")
+	assert.Nil(t, items)
+}
+
+func TestPaginatorCanceledContext(t *testing.T) {
+	t.Parallel()
+	c, err := NewMockClient(&badMockHTTPClient{})
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.PaginateTransfers().Next(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPaginatorRequestOptions(t *testing.T) {
+	t.Parallel()
+	var subAccounts []string
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		subAccounts = append(subAccounts, r.Header.Get(SubAccountIdHeader))
+		assert.Len(t, r.URL.Query()["page[size]"], 1)
+		newPagedHandler(t, 2)(w, r)
+	})
+	var response *Response
+	paginator := c.PaginateFinancialInstruments(&FinancialInstrumentsFilter{SubAccountID: "SA1"}, WithPageSize(2), WithSubAccount("SA1"), WithResponse(&response))
+	items, err := paginator.All(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, items, 4)
+	assert.Equal(t, []string{"SA1", "SA1"}, subAccounts)
+	assert.NotNil(t, response)
+}
+
+func TestPaginatorInvalidURI(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": []}`, nil))
+	_, err := NewPaginator[Gateway](c, "/gateways%zz").Next(context.Background())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,148 @@
+// Package promvgs exposes the metrics of a vgs.Client as a
+// prometheus.Collector. It is a module of its own, so only its users depend
+// on the Prometheus client.
+package promvgs
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ula/vgs-client/vgs"
+)
+
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+type Option func(*config)
+
+// WithNamespace prefixes the metric names, which default to vgs_.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels to every metric, e.g. the vault.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithBuckets sets the buckets of the duration histograms, defaults to
+// prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// Collector is a prometheus.Collector fed by Middleware and Hooks, see
+// Instrument.
+type Collector struct {
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	inFlight      *prometheus.GaugeVec
+	retries       *prometheus.CounterVec
+	tokenFetches  *prometheus.CounterVec
+	tokenDuration *prometheus.HistogramVec
+	authFailures  *prometheus.CounterVec
+}
+
+func NewCollector(options ...Option) *Collector {
+	c := &config{buckets: prometheus.DefBuckets}
+	for _, opt := range options {
+		opt(c)
+	}
+	opts := func(name, help string) prometheus.Opts {
+		return prometheus.Opts{Namespace: c.namespace, Name: name, Help: help, ConstLabels: c.constLabels}
+	}
+	histogramOpts := func(name, help string) prometheus.HistogramOpts {
+		return prometheus.HistogramOpts{Namespace: c.namespace, Name: name, Help: help, ConstLabels: c.constLabels, Buckets: c.buckets}
+	}
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts(opts("vgs_requests_total",
+			"API calls by endpoint, method and status.")), []string{"endpoint", "method", "status"}),
+		duration: prometheus.NewHistogramVec(histogramOpts("vgs_request_duration_seconds",
+			"Duration of API calls, retries included."), []string{"endpoint", "method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts(opts("vgs_requests_in_flight",
+			"API calls in progress.")), []string{"endpoint", "method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts(opts("vgs_request_retries_total",
+			"Retried attempts of API calls.")), []string{"endpoint", "method"}),
+		tokenFetches: prometheus.NewCounterVec(prometheus.CounterOpts(opts("vgs_auth_token_fetches_total",
+			"Token requests by grant type.")), []string{"grant_type"}),
+		tokenDuration: prometheus.NewHistogramVec(histogramOpts("vgs_auth_token_fetch_duration_seconds",
+			"Duration of token requests."), []string{"grant_type"}),
+		authFailures: prometheus.NewCounterVec(prometheus.CounterOpts(opts("vgs_auth_failures_total",
+			"Failed token requests by grant type and error class.")), []string{"grant_type", "error_class"}),
+	}
+}
+
+// Instrument feeds the collector from client. Register the collector once,
+// it can instrument several clients. Token requests of an
+// Options.Authenticator are only observed when Hooks are added to it, see
+// vgs.Client.AddHooks.
+func (c *Collector) Instrument(client *vgs.Client) {
+	client.Options.Middlewares = append([]vgs.Middleware{c.Middleware()}, client.Options.Middlewares...)
+	client.AddHooks(c.Hooks())
+}
+
+// Middleware records the calls of a client.
+func (c *Collector) Middleware() vgs.Middleware {
+	return func(next vgs.Handler) vgs.Handler {
+		return func(request *vgs.Request, req *http.Request) (*vgs.Response, error) {
+			endpoint := vgs.Route(request, req)
+			inFlight := c.inFlight.WithLabelValues(endpoint, req.Method)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			response, err := next(request, req)
+			status := "error"
+			if response != nil {
+				status = strconv.Itoa(response.StatusCode)
+			}
+			c.requests.WithLabelValues(endpoint, req.Method, status).Inc()
+			c.duration.WithLabelValues(endpoint, req.Method).Observe(time.Since(start).Seconds())
+			return response, err
+		}
+	}
+}
+
+// Hooks record retries and token requests.
+func (c *Collector) Hooks() vgs.Hooks {
+	return vgs.Hooks{
+		OnRetry: func(_ context.Context, req *http.Request, _ int, _ *http.Response, _ error) {
+			c.retries.WithLabelValues(vgs.Route(nil, req), req.Method).Inc()
+		},
+		OnTokenFetch: func(_ context.Context, event vgs.TokenFetchEvent) {
+			c.tokenFetches.WithLabelValues(event.GrantType).Inc()
+			c.tokenDuration.WithLabelValues(event.GrantType).Observe(event.Duration.Seconds())
+			if event.Err != nil {
+				c.authFailures.WithLabelValues(event.GrantType, vgs.ErrorClass(event.Err)).Inc()
+			}
+		},
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.requests, c.duration, c.inFlight, c.retries, c.tokenFetches, c.tokenDuration, c.authFailures}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
@@ -0,0 +1,116 @@
+package promvgs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ula/vgs-client/vgs"
+	"github.com/ula/vgs-client/vgs/internal/vgstest"
+)
+
+func newInstrumentedClient(t *testing.T, collector *Collector, tokenStatus int, handler http.HandlerFunc) *vgs.Client {
+	c := vgstest.NewClient(t, tokenStatus, handler)
+	collector.Instrument(c)
+	return c
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+	collector := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	assert.Nil(t, registry.Register(collector))
+
+	attempts := 0
+	c := newInstrumentedClient(t, collector, http.StatusOK, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	})
+	c.Options.RetryPolicy = &vgs.RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusServiceUnavailable}}
+	_, err := c.GetTransfer("TR1")
+	assert.Nil(t, err)
+	_, err = c.GetTransfer("missing")
+	assert.True(t, vgs.IsNotFound(err))
+
+	expected := `
+# HELP vgs_requests_total API calls by endpoint, method and status.
+# TYPE vgs_requests_total counter
+vgs_requests_total{endpoint="/transfers/{id}",method="GET",status="200"} 1
+vgs_requests_total{endpoint="/transfers/{id}",method="GET",status="404"} 1
+# HELP vgs_request_retries_total Retried attempts of API calls.
+# TYPE vgs_request_retries_total counter
+vgs_request_retries_total{endpoint="/transfers/{id}",method="GET"} 1
+# HELP vgs_auth_token_fetches_total Token requests by grant type.
+# TYPE vgs_auth_token_fetches_total counter
+vgs_auth_token_fetches_total{grant_type="client_credentials"} 1
+# HELP vgs_requests_in_flight API calls in progress.
+# TYPE vgs_requests_in_flight gauge
+vgs_requests_in_flight{endpoint="/transfers/{id}",method="GET"} 0
+`
+	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"vgs_requests_total", "vgs_request_retries_total", "vgs_auth_token_fetches_total", "vgs_requests_in_flight"))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "vgs_request_duration_seconds"))
+}
+
+func TestCollectorVaultRoute(t *testing.T) {
+	t.Parallel()
+	collector := NewCollector()
+	registry := prometheus.NewPedanticRegistry()
+	assert.Nil(t, registry.Register(collector))
+
+	attempts := 0
+	c := newInstrumentedClient(t, collector, http.StatusOK, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	c.Options.RetryPolicy = &vgs.RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusServiceUnavailable}}
+	_, err := c.VaultClient().Put("/cards/4111111111111111", map[string]string{}, nil)
+	assert.Nil(t, err)
+
+	expected := `
+# HELP vgs_requests_total API calls by endpoint, method and status.
+# TYPE vgs_requests_total counter
+vgs_requests_total{endpoint="vault",method="PUT",status="200"} 1
+# HELP vgs_request_retries_total Retried attempts of API calls.
+# TYPE vgs_request_retries_total counter
+vgs_request_retries_total{endpoint="vault",method="PUT"} 1
+`
+	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"vgs_requests_total", "vgs_request_retries_total"))
+}
+
+func TestCollectorAuthFailures(t *testing.T) {
+	t.Parallel()
+	collector := NewCollector(WithNamespace("payments"), WithConstLabels(prometheus.Labels{"vault": "tntabc"}))
+	c := newInstrumentedClient(t, collector, http.StatusUnauthorized, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unauthenticated request sent")
+	})
+	_, err := c.GetGateways()
+	assert.ErrorIs(t, err, vgs.ErrInvalidClient)
+
+	expected := `
+# HELP payments_vgs_auth_failures_total Failed token requests by grant type and error class.
+# TYPE payments_vgs_auth_failures_total counter
+payments_vgs_auth_failures_total{error_class="auth_invalid_client",grant_type="client_credentials",vault="tntabc"} 1
+# HELP payments_vgs_requests_total API calls by endpoint, method and status.
+# TYPE payments_vgs_requests_total counter
+payments_vgs_requests_total{endpoint="/gateways",method="GET",status="error",vault="tntabc"} 1
+`
+	assert.Nil(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"payments_vgs_auth_failures_total", "payments_vgs_requests_total"))
+}
@@ -0,0 +1,393 @@
+package vgs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in String and GoString output and in dumps.
+const Redacted = "[REDACTED]"
+
+var (
+	// panKeys hold card numbers, masked to their BIN and last 4 digits.
+	panKeys = map[string]bool{"number": true, "card_number": true, "pan": true, "account_number": true}
+	// secretFields hold values that are never shown.
+	secretFields = map[string]bool{
+		"cvc": true, "cvv": true, "cvc2": true, "cvv2": true, "security_code": true,
+		"client_secret": true, "client_assertion": true, "password": true, "secret": true,
+		"access_token": true, "refresh_token": true, "id_token": true, "value": true,
+		"secret_key": true, "api_key": true, "private_key": true, "transaction_key": true,
+	}
+	secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+	panPattern    = regexp.MustCompile(`\b\d{13,19}\b`)
+)
+
+// MaskPAN keeps the BIN and the last 4 digits of a card number, shorter
+// values keep their last 4 digits only.
+func MaskPAN(pan string) string {
+	if len(pan) >= 13 {
+		return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+	}
+	if len(pan) > 4 {
+		return strings.Repeat("*", len(pan)-4) + pan[len(pan)-4:]
+	}
+	return strings.Repeat("*", len(pan))
+}
+
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// redactText masks every card number found in s.
+func redactText(s string) string {
+	return panPattern.ReplaceAllStringFunc(s, func(digits string) string {
+		if !luhnValid(digits) {
+			return digits
+		}
+		return MaskPAN(digits)
+	})
+}
+
+func luhnValid(digits string) bool {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// redactor is implemented by types formatted with their secrets redacted.
+// redacted returns a copy of the value with the secrets redacted, converted
+// to a type without methods so formatting it does not recurse.
+type redactor interface {
+	redacted() interface{}
+}
+
+func redactedString(r redactor) string {
+	return fmt.Sprintf("%+v", r.redacted())
+}
+
+// redactedGoString formats the redacted copy with the type name of r.
+func redactedGoString(r redactor) string {
+	redacted := r.redacted()
+	return strings.Replace(fmt.Sprintf("%#v", redacted), fmt.Sprintf("%T", redacted), fmt.Sprintf("%T", r), 1)
+}
+
+type redactedCard Card
+
+func (c Card) redacted() interface{} {
+	c.Number = MaskPAN(c.Number)
+	c.Cvc = ""
+	return redactedCard(c)
+}
+
+// String masks the card number and drops the CVC.
+func (c Card) String() string   { return redactedString(c) }
+func (c Card) GoString() string { return redactedGoString(c) }
+
+type redactedOauthConfig OauthConfig
+
+func (c OauthConfig) redacted() interface{} {
+	c.ClientSecret = redactSecret(c.ClientSecret)
+	return redactedOauthConfig(c)
+}
+
+func (c OauthConfig) String() string   { return redactedString(c) }
+func (c OauthConfig) GoString() string { return redactedGoString(c) }
+
+type redactedOAuthToken OAuthToken
+
+func (t OAuthToken) redacted() interface{} {
+	t.AccessToken = redactSecret(t.AccessToken)
+	t.RefreshToken = redactSecret(t.RefreshToken)
+	return redactedOAuthToken(t)
+}
+
+func (t OAuthToken) String() string   { return redactedString(t) }
+func (t OAuthToken) GoString() string { return redactedGoString(t) }
+
+type redactedOptions Options
+
+func (o Options) redacted() interface{} {
+	o.ClientSecret = redactSecret(o.ClientSecret)
+	o.ProxyPassword = redactSecret(o.ProxyPassword)
+	return redactedOptions(o)
+}
+
+func (o Options) String() string   { return redactedString(o) }
+func (o Options) GoString() string { return redactedGoString(o) }
+
+type redactedRedactRequest RedactRequest
+
+func (r RedactRequest) redacted() interface{} {
+	r.Value = redactSecret(r.Value)
+	return redactedRedactRequest(r)
+}
+
+func (r RedactRequest) String() string   { return redactedString(r) }
+func (r RedactRequest) GoString() string { return redactedGoString(r) }
+
+type redactedAliasRecord AliasRecord
+
+func (r AliasRecord) redacted() interface{} {
+	r.Value = redactSecret(r.Value)
+	return redactedAliasRecord(r)
+}
+
+func (r AliasRecord) String() string   { return redactedString(r) }
+func (r AliasRecord) GoString() string { return redactedGoString(r) }
+
+type redactedStripeConfig StripeConfig
+
+func (c StripeConfig) redacted() interface{} {
+	c.SecretKey = redactSecret(c.SecretKey)
+	return redactedStripeConfig(c)
+}
+
+func (c StripeConfig) String() string   { return redactedString(c) }
+func (c StripeConfig) GoString() string { return redactedGoString(c) }
+
+type redactedAdyenConfig AdyenConfig
+
+func (c AdyenConfig) redacted() interface{} {
+	c.ApiKey = redactSecret(c.ApiKey)
+	return redactedAdyenConfig(c)
+}
+
+func (c AdyenConfig) String() string   { return redactedString(c) }
+func (c AdyenConfig) GoString() string { return redactedGoString(c) }
+
+type redactedBraintreeConfig BraintreeConfig
+
+func (c BraintreeConfig) redacted() interface{} {
+	c.PrivateKey = redactSecret(c.PrivateKey)
+	return redactedBraintreeConfig(c)
+}
+
+func (c BraintreeConfig) String() string   { return redactedString(c) }
+func (c BraintreeConfig) GoString() string { return redactedGoString(c) }
+
+type redactedAuthorizeNetConfig AuthorizeNetConfig
+
+func (c AuthorizeNetConfig) redacted() interface{} {
+	c.TransactionKey = redactSecret(c.TransactionKey)
+	return redactedAuthorizeNetConfig(c)
+}
+
+func (c AuthorizeNetConfig) String() string   { return redactedString(c) }
+func (c AuthorizeNetConfig) GoString() string { return redactedGoString(c) }
+
+type redactedCheckoutConfig CheckoutConfig
+
+func (c CheckoutConfig) redacted() interface{} {
+	c.SecretKey = redactSecret(c.SecretKey)
+	return redactedCheckoutConfig(c)
+}
+
+func (c CheckoutConfig) String() string   { return redactedString(c) }
+func (c CheckoutConfig) GoString() string { return redactedGoString(c) }
+
+type redactedRawGatewayConfig RawGatewayConfig
+
+func (r RawGatewayConfig) redacted() interface{} {
+	if len(r.Raw) > 0 {
+		r.Raw = redactBody(ContentTypeJSON, r.Raw)
+	}
+	return redactedRawGatewayConfig(r)
+}
+
+func (r RawGatewayConfig) String() string   { return redactedString(r) }
+func (r RawGatewayConfig) GoString() string { return redactedGoString(r) }
+
+// DumpRequest returns the request like httputil.DumpRequestOut, with
+// credentials and cardholder data redacted. The request body stays readable.
+func DumpRequest(req *http.Request, body bool) ([]byte, error) {
+	clone := req.Clone(req.Context())
+	clone.URL = redactURL(req.URL)
+	clone.Header = redactHeader(req.Header)
+	if body && req.Body != nil && req.Body != http.NoBody {
+		raw, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		redacted := redactBody(req.Header.Get("Content-Type"), raw)
+		clone.Body = io.NopCloser(bytes.NewReader(redacted))
+		clone.ContentLength = int64(len(redacted))
+	}
+	return httputil.DumpRequestOut(clone, body)
+}
+
+// DumpResponse returns the response like httputil.DumpResponse, with
+// credentials and cardholder data redacted. The response body stays
+// readable.
+func DumpResponse(resp *http.Response, body bool) ([]byte, error) {
+	clone := *resp
+	clone.Header = redactHeader(resp.Header)
+	if body && resp.Body != nil {
+		raw, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		redacted := redactBody(resp.Header.Get("Content-Type"), raw)
+		clone.Body = io.NopCloser(bytes.NewReader(redacted))
+		clone.ContentLength = int64(len(redacted))
+	}
+	return httputil.DumpResponse(&clone, body)
+}
+
+type dumpingHTTPClient struct {
+	client HTTPClient
+	out    io.Writer
+}
+
+// NewDumpingHTTPClient wraps client, writing redacted dumps of every request
+// and response to out. Meant for troubleshooting, set it as
+// Options.HTTPClient.
+func NewDumpingHTTPClient(client HTTPClient, out io.Writer) HTTPClient {
+	return &dumpingHTTPClient{client: client, out: out}
+}
+
+func (d *dumpingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if dump, err := DumpRequest(req, true); err == nil {
+		fmt.Fprintf(d.out, "%s\n\n", dump)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		fmt.Fprintf(d.out, "error: %s\n\n", redactText(err.Error()))
+		return resp, err
+	}
+	if dump, err := DumpResponse(resp, true); err == nil {
+		fmt.Fprintf(d.out, "%s\n\n", dump)
+	}
+	return resp, nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	raw, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+	return raw, err
+}
+
+func redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range secretHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, Redacted)
+		}
+	}
+	return header
+}
+
+func redactURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	redacted := *u
+	redacted.User = nil
+	redacted.Path = redactText(u.Path)
+	redacted.RawPath = ""
+	if u.RawQuery != "" {
+		redacted.RawQuery = redactValues(u.Query()).Encode()
+	}
+	return &redacted
+}
+
+// redactURI redacts a request uri for logging.
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return redactText(uri)
+	}
+	return redactURL(u).String()
+}
+
+func redactValues(values url.Values) url.Values {
+	redacted := url.Values{}
+	for key, list := range values {
+		for _, value := range list {
+			redacted.Add(key, redactField(key, value))
+		}
+	}
+	return redacted
+}
+
+func redactField(key, value string) string {
+	key = strings.ToLower(key)
+	switch {
+	case panKeys[key]:
+		return MaskPAN(value)
+	case secretFields[key]:
+		return redactSecret(value)
+	default:
+		return redactText(value)
+	}
+}
+
+func redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == ContentTypeForm {
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return []byte(redactValues(values).Encode())
+		}
+	}
+	var v interface{}
+	if json.Unmarshal(body, &v) == nil {
+		if redacted, err := json.Marshal(redactJSON("", v)); err == nil {
+			return redacted
+		}
+	}
+	return []byte(redactText(string(body)))
+}
+
+func redactJSON(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = redactJSON(k, value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJSON(key, value)
+		}
+		return v
+	case string:
+		return redactField(key, v)
+	case float64:
+		if panKeys[strings.ToLower(key)] || secretFields[strings.ToLower(key)] {
+			return Redacted
+		}
+		return v
+	default:
+		return v
+	}
+}
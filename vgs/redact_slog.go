@@ -0,0 +1,33 @@
+//go:build go1.21
+
+package vgs
+
+import "log/slog"
+
+func redactedLogValue(r redactor) slog.Value {
+	return slog.AnyValue(r.redacted())
+}
+
+func (c Card) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (c OauthConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (t OAuthToken) LogValue() slog.Value { return redactedLogValue(t) }
+
+func (o Options) LogValue() slog.Value { return redactedLogValue(o) }
+
+func (r RedactRequest) LogValue() slog.Value { return redactedLogValue(r) }
+
+func (r AliasRecord) LogValue() slog.Value { return redactedLogValue(r) }
+
+func (c StripeConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (c AdyenConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (c BraintreeConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (c AuthorizeNetConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (c CheckoutConfig) LogValue() slog.Value { return redactedLogValue(c) }
+
+func (r RawGatewayConfig) LogValue() slog.Value { return redactedLogValue(r) }
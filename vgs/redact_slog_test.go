@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package vgs
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactedLogValues(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("card",
+		"card", Card{Number: "4111111111111111", Cvc: "123"},
+		"token", &OAuthToken{AccessToken: "secret-access"},
+		"config", OauthConfig{ClientSecret: "secret-client"},
+		"record", AliasRecord{Value: "secret-value"},
+		"stripe", StripeConfig{SecretKey: "secret-stripe"},
+		"adyen", &AdyenConfig{ApiKey: "secret-adyen"},
+		"braintree", BraintreeConfig{PrivateKey: "secret-braintree"},
+		"authorize_net", AuthorizeNetConfig{TransactionKey: "secret-authorize-net"},
+		"checkout", CheckoutConfig{SecretKey: "secret-checkout"},
+	)
+	assert.NotContains(t, buf.String(), "4111111111111111")
+	assert.NotContains(t, buf.String(), "secret-")
+	assert.NotContains(t, buf.String(), `"123"`)
+	assert.Contains(t, buf.String(), "411111******1111")
+}
@@ -2,20 +2,147 @@ package vgs
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/json"
-	"log"
+	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 )
 
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+var SubAccountIdHeader = "VGS-Sub-Account-Id"
+
 type Request struct {
-	Method string      `json:"method"`
-	Uri    string      `json:"uri"`
-	Body   interface{} `json:"body"`
-	Values url.Values  `json:"data"`
+	Method  string      `json:"method"`
+	Uri     string      `json:"uri"`
+	Body    interface{} `json:"body"`
+	Values  url.Values  `json:"data"`
+	Headers http.Header `json:"headers"`
+	// Host the Uri is resolved against, defaults to the payment url.
+	BaseURL *url.URL `json:"-"`
+
+	ctx      context.Context
+	timeout  time.Duration
+	response **Response
+	// skips AuthenticationMiddleware, e.g. for inbound routes
+	unauthenticated bool
+	operation       string
 }
 
 type RequestOption func(*Request)
 
+func WithHeader(key, value string) RequestOption {
+	return func(r *Request) {
+		r.setHeader(key, value)
+	}
+}
+
+// WithQuery adds query parameters to the request url.
+func WithQuery(values url.Values) RequestOption {
+	return func(r *Request) {
+		if r.Values == nil {
+			r.Values = url.Values{}
+		}
+		for key, v := range values {
+			r.Values[key] = append(r.Values[key], v...)
+		}
+	}
+}
+
+// WithContext sets the context of a single call instead of Client.Ctx.
+func WithContext(ctx context.Context) RequestOption {
+	return func(r *Request) {
+		r.ctx = ctx
+	}
+}
+
+// WithTimeout limits the duration of a single call, retries included.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(r *Request) {
+		r.timeout = timeout
+	}
+}
+
+// WithResponse stores the response of the call, e.g. to read VGSRequestId or
+// IdempotentReplayed. It is set for API errors as well.
+func WithResponse(response **Response) RequestOption {
+	return func(r *Request) {
+		r.response = response
+	}
+}
+
+// WithBaseURL sends the request to another VGS API host.
+func WithBaseURL(baseUrl *url.URL) RequestOption {
+	return func(r *Request) {
+		r.BaseURL = baseUrl
+	}
+}
+
+// WithContentType sets the content type of the request body, e.g. for
+// VaultClient calls sending form or XML payloads.
+func WithContentType(contentType string) RequestOption {
+	return func(r *Request) {
+		r.setHeader("Content-Type", contentType)
+	}
+}
+
+func WithSubAccount(subAccountId string) RequestOption {
+	return func(r *Request) {
+		r.setHeader(SubAccountIdHeader, subAccountId)
+	}
+}
+
+// WithIdempotencyKey sets the key the API uses to deduplicate mutating calls.
+// Reuse the same key when repeating a logical operation, e.g. after a timeout.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(r *Request) {
+		r.setHeader(IdempotencyKeyHeader, key)
+	}
+}
+
+// NewIdempotencyKey returns a random UUIDv4 suitable for WithIdempotencyKey.
+func NewIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("vgs: unable to generate idempotency key: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// context returns the context of the call, derived from parent unless set
+// with WithContext, and bounded by WithTimeout.
+func (r *Request) context(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx := parent
+	if r.ctx != nil {
+		ctx = r.ctx
+	}
+	if r.timeout > 0 {
+		return context.WithTimeout(ctx, r.timeout)
+	}
+	return ctx, func() {}
+}
+
+func (r *Request) setHeader(key, value string) {
+	if r.Headers == nil {
+		r.Headers = http.Header{}
+	}
+	r.Headers.Set(key, value)
+}
+
+// isMutating reports whether the request changes state on the server and
+// cannot be safely repeated without an idempotency key.
+func (r *Request) isMutating() bool {
+	return r.Method == http.MethodPost || r.Method == http.MethodPatch
+}
+
 func NewJsonRequest(method, uri string, body interface{}, options ...RequestOption) *Request {
 	request := &Request{
 		Method: method,
@@ -38,14 +165,9 @@ func (r *Request) jsonBody() (*bytes.Buffer, error) {
 }
 
 func (r *Request) hasBody() bool {
-	return r.Body != ""
+	return r.Body != nil && r.Body != ""
 }
 
 func (r *Request) BuildURL(baseUrl *url.URL) (*url.URL, error) {
-	u, err := baseUrl.Parse(r.Uri)
-	if err != nil {
-		log.Printf("Unable to parse required uri: %s", r.Uri)
-		return nil, err
-	}
-	return u, nil
+	return baseUrl.Parse(r.Uri)
 }
@@ -0,0 +1,133 @@
+package vgs
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	key := NewIdempotencyKey()
+	assert.Regexp(t, uuid, key)
+	assert.NotEqual(t, key, NewIdempotencyKey())
+}
+
+func TestIdempotencyKeyGenerated(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	keys := []string{}
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		mu.Unlock()
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"data": []}`))
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	})
+	c.Options.RetryPolicy = &RetryPolicy{MaxAttempts: 2, RetryStatuses: []int{http.StatusServiceUnavailable}}
+	_, err := c.CreatePaymentCard(&CreatePaymentCardRequest{Card: &Card{}})
+	assert.Nil(t, err)
+	_, err = c.GetFinancialInstruments()
+	assert.Nil(t, err)
+	assert.Len(t, keys, 3)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+	assert.Empty(t, keys[2])
+}
+
+func TestIdempotencyKeyOption(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "my-key", r.Header.Get(IdempotencyKeyHeader))
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.Write([]byte(`{"data": {}}`))
+	})
+	resp, err := c.Post("/verfications", &VerificationsRequest{}, nil, WithIdempotencyKey("my-key"))
+	assert.Nil(t, err)
+	assert.True(t, resp.IdempotentReplayed)
+
+	_, err = c.CreateVerifications(&VerificationsRequest{}, WithIdempotencyKey("my-key"))
+	assert.Nil(t, err)
+}
+
+func TestIdempotencyKeyDisabled(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(IdempotencyKeyHeader))
+		w.Write([]byte(`{"data": {}}`))
+	})
+	c.Options.DisableIdempotencyKeys = true
+	resp, err := c.Post("/financial_instruments", &CreatePaymentCardRequest{}, nil)
+	assert.Nil(t, err)
+	assert.False(t, resp.IdempotentReplayed)
+}
+
+func TestRequestOptions(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "value", r.Header.Get("X-Custom"))
+		assert.Equal(t, "SA1", r.Header.Get(SubAccountIdHeader))
+		assert.Equal(t, []string{"1", "2"}, r.URL.Query()["a"])
+		assert.Equal(t, "visa", r.URL.Query().Get("card_brand"))
+		w.Write([]byte(`{"data": []}`))
+	})
+	_, err := c.FilterFinancialInstruments(
+		&FinancialInstrumentsFilter{CardBrand: "visa"},
+		WithHeader("X-Custom", "value"),
+		WithSubAccount("SA1"),
+		WithQuery(url.Values{"a": []string{"1", "2"}}),
+	)
+	assert.Nil(t, err)
+}
+
+func TestRequestWithContext(t *testing.T) {
+	t.Parallel()
+	c, err := NewMockClient(&badMockHTTPClient{})
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetGateways(WithContext(ctx))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, c.Ctx.Err())
+}
+
+func TestDoRequestContext(t *testing.T) {
+	t.Parallel()
+	c, err := NewMockClient(&flakyHTTPClient{failures: 100})
+	assert.Nil(t, err)
+	c.Options.RetryPolicy = &RetryPolicy{MaxAttempts: 100, BackoffBase: time.Hour, RetryNetworkErrors: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := c.NewRequestContext(ctx, NewJsonRequest(http.MethodGet, "/transfers/XF123", nil))
+	assert.Nil(t, err)
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = c.Do(req, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Nil(t, c.Ctx.Err())
+}
+
+func TestRequestWithTimeout(t *testing.T) {
+	t.Parallel()
+	c, err := NewMockClient(&flakyHTTPClient{failures: 100})
+	assert.Nil(t, err)
+	c.Options.RetryPolicy = &RetryPolicy{MaxAttempts: 100, BackoffBase: time.Hour, RetryNetworkErrors: true}
+	start := time.Now()
+	_, err = c.GetTransfer("XF123", WithTimeout(10*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
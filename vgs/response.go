@@ -1,10 +1,10 @@
 package vgs
 
 import (
-	"encoding/json"
+	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"strings"
 )
 
 var (
@@ -35,13 +35,24 @@ type Response struct {
 
 	VGSRequestId string
 	TraceId      string
+	// The API answered with the stored result of an earlier call made with
+	// the same idempotency key.
+	IdempotentReplayed bool
 }
 
+// NewResponse wraps r, see ReadResponse for reporting body read failures.
 func NewResponse(r *http.Response) *Response {
+	response, _ := ReadResponse(r)
+	return response
+}
+
+// ReadResponse wraps r and reads its body into RawBody. The partial response
+// is returned along with the read error.
+func ReadResponse(r *http.Response) (*Response, error) {
 	response := &Response{Response: r}
 	response.parseHeaders()
-	response.readBody()
-	return response
+	err := response.readBody()
+	return response, err
 }
 
 func (r *Response) parseHeaders() {
@@ -51,24 +62,21 @@ func (r *Response) parseHeaders() {
 	if traceId := r.Header.Get(TraceId); traceId != "" {
 		r.TraceId = traceId
 	}
+	r.IdempotentReplayed = strings.EqualFold(r.Header.Get(IdempotentReplayedHeader), "true")
 }
 
-func (r *Response) readBody() {
+func (r *Response) readBody() error {
 	body, err := io.ReadAll(r.Body)
+	r.RawBody = body
 	if err != nil {
-		log.Fatalf("Unable to read response body: %v\n", err)
-		return
+		return fmt.Errorf("unable to read response body: %w", err)
 	}
-	r.RawBody = body
+	return nil
 }
 
 func ValidateResponse(r *Response) error {
 	if r.StatusCode >= 200 && r.StatusCode <= 299 {
 		return nil
 	}
-	var vgsError VGSError
-	if err := json.Unmarshal(r.RawBody, &vgsError); err != nil {
-		return err
-	}
-	return vgsError
+	return NewAPIError(r)
 }
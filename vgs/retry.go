@@ -0,0 +1,132 @@
+package vgs
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	// Total number of attempts including the first one. Values below 2
+	// disable retries.
+	MaxAttempts int
+	// Delay before the first retry, doubled on every next attempt.
+	BackoffBase time.Duration
+	// Upper bound of the backoff delay.
+	BackoffCap time.Duration
+	// Fraction of the backoff delay randomized, between 0 and 1.
+	Jitter float64
+	// Response status codes that are retried.
+	RetryStatuses []int
+	// Retry requests that failed without a response, e.g. connection resets.
+	RetryNetworkErrors bool
+	// Wait for the duration of the Retry-After header when it is present.
+	RespectRetryAfter bool
+	// Retry-After values longer than this are not waited for, the response
+	// is returned instead. Zero means no limit.
+	MaxRetryAfter time.Duration
+}
+
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BackoffBase: 200 * time.Millisecond,
+		BackoffCap:  5 * time.Second,
+		Jitter:      0.5,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+		RespectRetryAfter:  true,
+		MaxRetryAfter:      time.Minute,
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// retryable reports whether the attempt that ended with resp or err should
+// be retried.
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if !isIdempotent(req) {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return p.RetryNetworkErrors
+	}
+	for _, status := range p.RetryStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before the given retry attempt, starting
+// at 1. The second value is false when Retry-After exceeds MaxRetryAfter.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if p.RespectRetryAfter && resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxRetryAfter > 0 && wait > p.MaxRetryAfter {
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+	backoff := float64(p.BackoffBase) * math.Pow(2, float64(attempt-1))
+	if p.BackoffCap > 0 && backoff > float64(p.BackoffCap) {
+		backoff = float64(p.BackoffCap)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(backoff), true
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
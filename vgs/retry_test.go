@@ -0,0 +1,146 @@
+package vgs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryClient(handler http.HandlerFunc) *Client {
+	c := NewMockClientWithHandler(handler)
+	c.Options.RetryPolicy = DefaultRetryPolicy()
+	c.Options.RetryPolicy.BackoffBase = time.Millisecond
+	c.Options.RetryPolicy.BackoffCap = 2 * time.Millisecond
+	return c
+}
+
+func newFlakyHandler(t *testing.T, failures int32, status int, attempts *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			body, _ := io.ReadAll(r.Body)
+			if r.Method == http.MethodPost {
+				assert.JSONEq(t, `{"amount": 100}`, string(body))
+			}
+		}
+		if atomic.AddInt32(attempts, 1) <= failures {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error": "error", "error_description": "dummy error"}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"id": "XF123"}}`))
+	}
+}
+
+func TestRetryIdempotentRequest(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	c := newRetryClient(newFlakyHandler(t, 2, http.StatusServiceUnavailable, &attempts))
+	transfer, err := c.GetTransfer("XF123")
+	assert.Nil(t, err)
+	assert.Equal(t, "XF123", transfer.Data.ID)
+	assert.Equal(t, int32(3), attempts)
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	c := newRetryClient(newFlakyHandler(t, 5, http.StatusBadGateway, &attempts))
+	_, err := c.GetTransfer("XF123")
+	assert.ErrorContains(t, err, "dummy")
+	assert.Equal(t, int32(3), attempts)
+}
+
+func TestRetryNonRetryableStatus(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	c := newRetryClient(newFlakyHandler(t, 5, http.StatusBadRequest, &attempts))
+	_, err := c.GetTransfer("XF123")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestRetryPostRequiresIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	c := newRetryClient(newFlakyHandler(t, 1, http.StatusServiceUnavailable, &attempts))
+	c.Options.DisableIdempotencyKeys = true
+	_, err := c.Post("/transfers", map[string]int{"amount": 100}, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), attempts)
+
+	attempts = 0
+	_, err = c.Post("/transfers", map[string]int{"amount": 100}, nil, WithIdempotencyKey("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), attempts)
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+	t.Parallel()
+	var attempts int32
+	c := newRetryClient(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error": "rate_limited", "error_description": "dummy error"}`))
+	})
+	_, err := c.GetGateways()
+	assert.ErrorContains(t, err, "dummy")
+	assert.Equal(t, int32(1), attempts)
+}
+
+type flakyHTTPClient struct {
+	failures int32
+	attempts int32
+	next     HTTPClient
+}
+
+func (f *flakyHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&f.attempts, 1) <= f.failures {
+		return nil, errors.New("connection reset by peer")
+	}
+	return f.next.Do(req)
+}
+
+func TestRetryNetworkError(t *testing.T) {
+	t.Parallel()
+	httpClient := &flakyHTTPClient{failures: 2, next: &mockHTTPClient{newMockHandler(http.StatusOK, `{"data": []}`, nil)}}
+	c, err := NewMockClient(httpClient)
+	assert.Nil(t, err)
+	c.Options.RetryPolicy = &RetryPolicy{MaxAttempts: 3, RetryNetworkErrors: true}
+	gateways, err := c.GetGateways()
+	assert.Nil(t, err)
+	assert.NotNil(t, gateways)
+	assert.Equal(t, int32(3), httpClient.attempts)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+	policy := &RetryPolicy{BackoffBase: 100 * time.Millisecond, BackoffCap: time.Second}
+	for attempt, expected := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		delay, ok := policy.delay(attempt+1, nil)
+		assert.True(t, ok)
+		assert.Equal(t, expected*time.Millisecond, delay)
+	}
+
+	policy.Jitter = 1
+	for i := 0; i < 100; i++ {
+		delay, _ := policy.delay(1, nil)
+		assert.True(t, delay >= 0 && delay <= 100*time.Millisecond)
+	}
+
+	policy.RespectRetryAfter = true
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	delay, ok := policy.delay(1, resp)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, delay)
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	delay, ok = policy.delay(1, resp)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+}
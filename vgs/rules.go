@@ -0,0 +1,331 @@
+package vgs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OperatorEquals       = "equals"
+	OperatorNotEquals    = "not_equals"
+	OperatorIn           = "in"
+	OperatorNotIn        = "not_in"
+	OperatorGreaterThan  = "greater_than"
+	OperatorGreaterEqual = "greater_than_or_equal"
+	OperatorLessThan     = "less_than"
+	OperatorLessEqual    = "less_than_or_equal"
+	OperatorStartsWith   = "starts_with"
+)
+
+const (
+	FieldAmount       = "amount"
+	FieldCurrency     = "currency"
+	FieldSource       = "source"
+	FieldSubAccountID = "sub_account_id"
+	FieldCardBrand    = "card.brand"
+	FieldCardBin      = "card.bin"
+	FieldCardCountry  = "card.country"
+)
+
+var ErrNoGatewayMatched = errors.New("no routing rule matched and no default gateway is set")
+
+type Condition struct {
+	// Transfer field the condition is evaluated against, e.g. amount or card.brand.
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+type Rule struct {
+	ID          string `json:"id,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Gateway the transfer is routed to when all conditions match.
+	GatewayID  string      `json:"gateway_id"`
+	Conditions []Condition `json:"conditions"`
+	// Rules are evaluated in ascending ordinal order, first match wins.
+	Ordinal   int       `json:"ordinal"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+type Rules struct {
+	Response
+	Data []Rule `json:"data,omitempty"`
+}
+
+type RuleItem struct {
+	Data Rule `json:"data,omitempty"`
+}
+
+type CreateRuleRequest struct {
+	Description string      `json:"description,omitempty"`
+	GatewayID   string      `json:"gateway_id"`
+	Conditions  []Condition `json:"conditions"`
+	Ordinal     int         `json:"ordinal"`
+}
+
+type UpdateRuleRequest struct {
+	Description string      `json:"description,omitempty"`
+	GatewayID   string      `json:"gateway_id,omitempty"`
+	Conditions  []Condition `json:"conditions,omitempty"`
+	Ordinal     *int        `json:"ordinal,omitempty"`
+}
+
+func ruleUri(id string) string {
+	return fmt.Sprintf("/rules/%s", url.PathEscape(id))
+}
+
+func (c *Client) GetRules(options ...RequestOption) (*Rules, error) {
+	return c.GetRulesContext(c.Ctx, options...)
+}
+
+func (c *Client) GetRulesContext(ctx context.Context, options ...RequestOption) (*Rules, error) {
+	rules := &Rules{}
+	_, err := c.GetContext(ctx, "/rules", rules, options...)
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (c *Client) GetRule(id string, options ...RequestOption) (*RuleItem, error) {
+	return c.GetRuleContext(c.Ctx, id, options...)
+}
+
+func (c *Client) GetRuleContext(ctx context.Context, id string, options ...RequestOption) (*RuleItem, error) {
+	resp := &RuleItem{}
+	_, err := c.GetContext(ctx, ruleUri(id), resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateRule(body *CreateRuleRequest, options ...RequestOption) (*RuleItem, error) {
+	return c.CreateRuleContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreateRuleContext(ctx context.Context, body *CreateRuleRequest, options ...RequestOption) (*RuleItem, error) {
+	resp := &RuleItem{}
+	_, err := c.PostContext(ctx, "/rules", body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) UpdateRule(id string, body *UpdateRuleRequest, options ...RequestOption) (*RuleItem, error) {
+	return c.UpdateRuleContext(c.Ctx, id, body, options...)
+}
+
+func (c *Client) UpdateRuleContext(ctx context.Context, id string, body *UpdateRuleRequest, options ...RequestOption) (*RuleItem, error) {
+	resp := &RuleItem{}
+	_, err := c.PatchContext(ctx, ruleUri(id), body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeleteRule(id string, options ...RequestOption) error {
+	return c.DeleteRuleContext(c.Ctx, id, options...)
+}
+
+func (c *Client) DeleteRuleContext(ctx context.Context, id string, options ...RequestOption) error {
+	_, err := c.DeleteContext(ctx, ruleUri(id), nil, options...)
+	return err
+}
+
+// ReorderError is returned when reordering stopped at a rule, the rules in
+// Updated already have their new ordinal.
+type ReorderError struct {
+	Updated []string
+	ID      string
+	Err     error
+}
+
+func (e *ReorderError) Error() string {
+	return fmt.Sprintf("reorder rule %s after updating %d rules: %v", e.ID, len(e.Updated), e.Err)
+}
+
+func (e *ReorderError) Unwrap() error {
+	return e.Err
+}
+
+// ReorderRules sets the ordinal of every rule to its position in ids, one
+// update at a time. When an update fails the rules updated so far are
+// returned with a *ReorderError.
+//
+// The options apply to every update. A WithIdempotencyKey key is suffixed
+// with the rule id, so each update has its own key and repeating the reorder
+// with the same key is deduplicated per rule. WithResponse stores the
+// response of the last update, the failed one on error.
+func (c *Client) ReorderRules(ids []string, options ...RequestOption) ([]Rule, error) {
+	return c.ReorderRulesContext(c.Ctx, ids, options...)
+}
+
+func (c *Client) ReorderRulesContext(ctx context.Context, ids []string, options ...RequestOption) ([]Rule, error) {
+	var request Request
+	for _, option := range options {
+		option(&request)
+	}
+	key := request.Headers.Get(IdempotencyKeyHeader)
+	rules := make([]Rule, 0, len(ids))
+	for i, id := range ids {
+		ordinal := i
+		updateOptions := options
+		if key != "" {
+			updateOptions = append(options[:len(options):len(options)], WithIdempotencyKey(key+"-"+id))
+		}
+		resp, err := c.UpdateRuleContext(ctx, id, &UpdateRuleRequest{Ordinal: &ordinal}, updateOptions...)
+		if err != nil {
+			return rules, &ReorderError{Updated: ids[:i:i], ID: id, Err: err}
+		}
+		rules = append(rules, resp.Data)
+	}
+	return rules, nil
+}
+
+// RoutingInput holds the transfer attributes routing rules are evaluated on.
+type RoutingInput struct {
+	Amount       int
+	Currency     string
+	Source       string
+	SubAccountID string
+	CardBrand    string
+	CardBin      string
+	// Issuing country of the card, e.g. from a BIN lookup. Card has no such
+	// field, so NewRoutingInput leaves it empty.
+	CardCountry string
+}
+
+func NewRoutingInput(transfer *CreateTransferRequest, card *Card) *RoutingInput {
+	input := &RoutingInput{
+		Amount:       transfer.Amount,
+		Currency:     transfer.Currency,
+		Source:       transfer.Source,
+		SubAccountID: transfer.SubAccountID,
+	}
+	if card != nil {
+		input.CardBrand = card.Brand
+		if len(card.Number) >= 6 {
+			input.CardBin = card.Number[:6]
+		}
+	}
+	return input
+}
+
+func (i *RoutingInput) field(name string) (string, error) {
+	switch name {
+	case FieldAmount:
+		return strconv.Itoa(i.Amount), nil
+	case FieldCurrency:
+		return i.Currency, nil
+	case FieldSource:
+		return i.Source, nil
+	case FieldSubAccountID:
+		return i.SubAccountID, nil
+	case FieldCardBrand:
+		return i.CardBrand, nil
+	case FieldCardBin:
+		return i.CardBin, nil
+	case FieldCardCountry:
+		return i.CardCountry, nil
+	}
+	return "", fmt.Errorf("unknown rule field: %s", name)
+}
+
+// Match reports whether the condition holds for the input.
+func (c *Condition) Match(input *RoutingInput) (bool, error) {
+	value, err := input.field(c.Field)
+	if err != nil {
+		return false, err
+	}
+	if len(c.Values) == 0 {
+		return false, fmt.Errorf("condition on %s has no values", c.Field)
+	}
+	switch c.Operator {
+	case OperatorEquals:
+		return strings.EqualFold(value, c.Values[0]), nil
+	case OperatorNotEquals:
+		return !strings.EqualFold(value, c.Values[0]), nil
+	case OperatorIn, OperatorNotIn:
+		found := false
+		for _, v := range c.Values {
+			if strings.EqualFold(value, v) {
+				found = true
+				break
+			}
+		}
+		return found == (c.Operator == OperatorIn), nil
+	case OperatorStartsWith:
+		for _, v := range c.Values {
+			if len(value) >= len(v) && strings.EqualFold(value[:len(v)], v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case OperatorGreaterThan, OperatorGreaterEqual, OperatorLessThan, OperatorLessEqual:
+		actual, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, fmt.Errorf("field %s is not numeric", c.Field)
+		}
+		expected, err := strconv.ParseFloat(c.Values[0], 64)
+		if err != nil {
+			return false, fmt.Errorf("condition value %q is not numeric", c.Values[0])
+		}
+		switch c.Operator {
+		case OperatorGreaterThan:
+			return actual > expected, nil
+		case OperatorGreaterEqual:
+			return actual >= expected, nil
+		case OperatorLessThan:
+			return actual < expected, nil
+		default:
+			return actual <= expected, nil
+		}
+	}
+	return false, fmt.Errorf("unknown rule operator: %s", c.Operator)
+}
+
+// Match reports whether all conditions of the rule hold for the input.
+func (r *Rule) Match(input *RoutingInput) (bool, error) {
+	for i := range r.Conditions {
+		ok, err := r.Conditions[i].Match(input)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// EvaluateRules predicts the id of the gateway a transfer is routed to: the
+// gateway of the first matching rule by ordinal, or the default gateway.
+func EvaluateRules(rules []Rule, gateways []Gateway, input *RoutingInput) (string, error) {
+	ordered := make([]Rule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Ordinal < ordered[j].Ordinal
+	})
+	for i := range ordered {
+		ok, err := ordered[i].Match(input)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return ordered[i].GatewayID, nil
+		}
+	}
+	for _, gateway := range gateways {
+		if gateway.DefaultGateway {
+			return gateway.Id, nil
+		}
+	}
+	return "", ErrNoGatewayMatched
+}
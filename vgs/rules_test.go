@@ -0,0 +1,151 @@
+package vgs
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRules(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/rules", http.StatusOK, `{"data": [{"id": "RL1", "gateway_id": "GW1", "conditions": [{"field": "currency", "operator": "equals", "values": ["EUR"]}]}]}`))
+	rules, err := c.GetRules()
+	assert.Nil(t, err)
+	assert.Len(t, rules.Data, 1)
+	assert.Equal(t, "EUR", rules.Data[0].Conditions[0].Values[0])
+}
+
+func TestCreateRule(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"gateway_id": "GW1", "ordinal": 0, "conditions": [{"field": "amount", "operator": "greater_than", "values": ["1000"]}]}`, string(body))
+		w.Write([]byte(`{"data": {"id": "RL1", "gateway_id": "GW1"}}`))
+	})
+	rule, err := c.CreateRule(&CreateRuleRequest{
+		GatewayID:  "GW1",
+		Conditions: []Condition{{Field: FieldAmount, Operator: OperatorGreaterThan, Values: []string{"1000"}}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "RL1", rule.Data.ID)
+}
+
+func TestReorderRules(t *testing.T) {
+	t.Parallel()
+	var bodies []string
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.URL.Path+" "+string(body))
+		w.Write([]byte(`{"data": {}}`))
+	})
+	rules, err := c.ReorderRules([]string{"RL2", "RL1"})
+	assert.Nil(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, []string{`/rules/RL2 {"ordinal":0}` + "\n", `/rules/RL1 {"ordinal":1}` + "\n"}, bodies)
+}
+
+func TestReorderRulesPartialFailure(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "reorder", r.Header.Get("X-Test"))
+		if r.URL.Path == "/rules/RL3" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data": {"id": "` + strings.TrimPrefix(r.URL.Path, "/rules/") + `"}}`))
+	})
+	rules, err := c.ReorderRules([]string{"RL1", "RL2", "RL3", "RL4"}, WithHeader("X-Test", "reorder"))
+	var reorderError *ReorderError
+	if assert.True(t, errors.As(err, &reorderError)) {
+		assert.Equal(t, []string{"RL1", "RL2"}, reorderError.Updated)
+		assert.Equal(t, "RL3", reorderError.ID)
+	}
+	assert.True(t, IsNotFound(err))
+	if assert.Len(t, rules, 2) {
+		assert.Equal(t, "RL2", rules[1].ID)
+	}
+}
+
+func TestReorderRulesIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	var keys []string
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		w.Write([]byte(`{"data": {}}`))
+	})
+	_, err := c.ReorderRules([]string{"RL2", "RL1"}, WithIdempotencyKey("reorder"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"reorder-RL2", "reorder-RL1"}, keys)
+}
+
+func TestDeleteRule(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodDelete, "/rules/RL1", http.StatusNoContent, ""))
+	assert.Nil(t, c.DeleteRule("RL1"))
+}
+
+func TestEvaluateRules(t *testing.T) {
+	t.Parallel()
+	rules := []Rule{
+		{GatewayID: "GW-large", Ordinal: 2, Conditions: []Condition{
+			{Field: FieldAmount, Operator: OperatorGreaterEqual, Values: []string{"10000"}},
+		}},
+		{GatewayID: "GW-eu-visa", Ordinal: 1, Conditions: []Condition{
+			{Field: FieldCurrency, Operator: OperatorIn, Values: []string{"EUR", "GBP"}},
+			{Field: FieldCardBrand, Operator: OperatorEquals, Values: []string{"visa"}},
+		}},
+		{GatewayID: "GW-bin", Ordinal: 3, Conditions: []Condition{
+			{Field: FieldCardBin, Operator: OperatorStartsWith, Values: []string{"4111"}},
+		}},
+	}
+	gateways := []Gateway{{Id: "GW-default", DefaultGateway: true}, {Id: "GW-other"}}
+	testCases := []struct {
+		transfer *CreateTransferRequest
+		card     *Card
+		expected string
+	}{
+		{&CreateTransferRequest{Amount: 500, Currency: "eur"}, &Card{Brand: "Visa"}, "GW-eu-visa"},
+		{&CreateTransferRequest{Amount: 50000, Currency: "EUR"}, &Card{Brand: "visa"}, "GW-eu-visa"},
+		{&CreateTransferRequest{Amount: 50000, Currency: "USD"}, nil, "GW-large"},
+		{&CreateTransferRequest{Amount: 500, Currency: "USD"}, &Card{Number: "4111111111111111"}, "GW-bin"},
+		{&CreateTransferRequest{Amount: 500, Currency: "USD"}, &Card{Brand: "visa"}, "GW-default"},
+	}
+	for _, testCase := range testCases {
+		gatewayId, err := EvaluateRules(rules, gateways, NewRoutingInput(testCase.transfer, testCase.card))
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expected, gatewayId)
+	}
+
+	_, err := EvaluateRules(rules, nil, NewRoutingInput(&CreateTransferRequest{Amount: 1}, nil))
+	assert.ErrorIs(t, err, ErrNoGatewayMatched)
+
+	condition := Condition{Field: FieldCardBrand, Operator: OperatorStartsWith, Values: []string{"MASTER"}}
+	ok, err := condition.Match(NewRoutingInput(&CreateTransferRequest{}, &Card{Brand: "mastercard"}))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, _ = condition.Match(NewRoutingInput(&CreateTransferRequest{}, &Card{Brand: "mc"}))
+	assert.False(t, ok)
+
+	condition = Condition{Field: FieldCardCountry, Operator: OperatorEquals, Values: []string{"DE"}}
+	input := NewRoutingInput(&CreateTransferRequest{}, &Card{BillingAddress: &ContactAddress{Country: "DE"}})
+	ok, _ = condition.Match(input)
+	assert.False(t, ok)
+	input.CardCountry = "DE"
+	ok, _ = condition.Match(input)
+	assert.True(t, ok)
+}
+
+func TestEvaluateRulesInvalid(t *testing.T) {
+	t.Parallel()
+	input := NewRoutingInput(&CreateTransferRequest{Amount: 1}, nil)
+	_, err := EvaluateRules([]Rule{{Conditions: []Condition{{Field: "dummy", Operator: OperatorEquals, Values: []string{"x"}}}}}, nil, input)
+	assert.ErrorContains(t, err, "unknown rule field")
+	_, err = EvaluateRules([]Rule{{Conditions: []Condition{{Field: FieldAmount, Operator: "dummy", Values: []string{"x"}}}}}, nil, input)
+	assert.ErrorContains(t, err, "unknown rule operator")
+}
@@ -0,0 +1,213 @@
+package vgs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenStore shares OAuth tokens between authenticators, possibly running
+// in different processes. Load returns nil when no token is stored.
+type TokenStore interface {
+	Load(ctx context.Context, key string) (*OAuthToken, error)
+	Save(ctx context.Context, key string, token *OAuthToken) error
+}
+
+// TokenLocker is implemented by stores that can serialize token fetches
+// between processes, so only one of them calls the auth endpoint.
+type TokenLocker interface {
+	Lock(ctx context.Context, key string) (unlock func(), err error)
+}
+
+// storedToken is the persisted form of a token.
+type storedToken struct {
+	Token     *OAuthToken `json:"token"`
+	CreatedAt time.Time   `json:"created_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+func newStoredToken(token *OAuthToken) *storedToken {
+	return &storedToken{Token: token, CreatedAt: token.CreatedAt, ExpiresAt: token.ExpiresAt()}
+}
+
+func (s *storedToken) token() *OAuthToken {
+	if s == nil || s.Token == nil {
+		return nil
+	}
+	token := *s.Token
+	token.CreatedAt = s.CreatedAt
+	return &token
+}
+
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]*OAuthToken
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]*OAuthToken{}}
+}
+
+func (m *MemoryTokenStore) Load(ctx context.Context, key string) (*OAuthToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tokens[key], nil
+}
+
+func (m *MemoryTokenStore) Save(ctx context.Context, key string, token *OAuthToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[key] = token
+	return nil
+}
+
+// FileTokenStore keeps one file per key in a directory, e.g. a volume shared
+// by the pods of a deployment. Writes are atomic and Lock uses lock files,
+// so concurrent writers never see a partial token.
+type FileTokenStore struct {
+	Dir string
+	// Lock files older than this are considered left behind by a crashed
+	// process and taken over, where flock is not available. It must exceed
+	// the duration of a token fetch.
+	StaleLockAge time.Duration
+	// How often Lock checks whether the lock was released.
+	PollInterval time.Duration
+}
+
+func NewFileTokenStore(dir string) *FileTokenStore {
+	return &FileTokenStore{
+		Dir:          dir,
+		StaleLockAge: 2 * time.Minute,
+		PollInterval: 50 * time.Millisecond,
+	}
+}
+
+func (f *FileTokenStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.Dir, "vgs-token-"+hex.EncodeToString(sum[:8]))
+}
+
+func (f *FileTokenStore) Load(ctx context.Context, key string) (*OAuthToken, error) {
+	data, err := os.ReadFile(f.path(key) + ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored storedToken
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	return stored.token(), nil
+}
+
+func (f *FileTokenStore) Save(ctx context.Context, key string, token *OAuthToken) error {
+	data, err := json.Marshal(newStoredToken(token))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.Dir, ".vgs-token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key)+".json")
+}
+
+// Lock serializes token fetches for key. On platforms with flock the lock is
+// released by the kernel when its holder dies, elsewhere lock files older
+// than StaleLockAge are taken over.
+func (f *FileTokenStore) Lock(ctx context.Context, key string) (func(), error) {
+	if err := os.MkdirAll(f.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	return f.lockFile(ctx, f.path(key)+".lock")
+}
+
+func (f *FileTokenStore) pollInterval() time.Duration {
+	if f.PollInterval <= 0 {
+		return 50 * time.Millisecond
+	}
+	return f.PollInterval
+}
+
+// lockExclusive creates the lock file holding a random owner nonce, so a
+// holder whose lock was taken over as stale does not remove the lock of the
+// new holder.
+func (f *FileTokenStore) lockExclusive(ctx context.Context, lock string) (func(), error) {
+	nonce := NewIdempotencyKey()
+	for {
+		err := f.createLock(lock, nonce)
+		if err == nil {
+			return func() { removeOwnedLock(lock, nonce) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && f.StaleLockAge > 0 && time.Since(info.ModTime()) > f.StaleLockAge {
+			f.breakStaleLock(lock, nonce)
+			continue
+		}
+		if err := sleepContext(ctx, f.pollInterval()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// createLock links a file holding nonce to lock, which fails with
+// os.ErrExist while the lock is held and never exposes an empty lock.
+func (f *FileTokenStore) createLock(lock, nonce string) error {
+	tmp, err := os.CreateTemp(f.Dir, ".vgs-lock-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(nonce); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Link(tmp.Name(), lock)
+}
+
+// breakStaleLock moves the stale lock aside atomically. When another process
+// replaced it with a fresh lock in the meantime, that lock is put back.
+func (f *FileTokenStore) breakStaleLock(lock, nonce string) {
+	stale, err := os.ReadFile(lock)
+	if err != nil {
+		return
+	}
+	moved := lock + "." + nonce + ".stale"
+	if err := os.Rename(lock, moved); err != nil {
+		return
+	}
+	defer os.Remove(moved)
+	if current, err := os.ReadFile(moved); err == nil && string(current) != string(stale) {
+		os.Link(moved, lock)
+	}
+}
+
+func removeOwnedLock(lock, nonce string) {
+	if owner, err := os.ReadFile(lock); err == nil && string(owner) == nonce {
+		os.Remove(lock)
+	}
+}
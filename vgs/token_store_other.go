@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package vgs
+
+import "context"
+
+func (f *FileTokenStore) lockFile(ctx context.Context, lock string) (func(), error) {
+	return f.lockExclusive(ctx, lock)
+}
@@ -0,0 +1,200 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTokenStoreShared(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	first := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 300}`, atomic.AddInt32(&fetches, 1))
+	})
+	second := NewOAuthAuthenticator("test-client", "test-secret")
+	second.OAuthURL = first.OAuthURL
+	second.Store = first.Store
+
+	token, err := first.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	token, err = second.Authenticate()
+	assert.Nil(t, err)
+	assert.Equal(t, "token-1", token.AccessToken)
+	assert.Equal(t, int32(1), fetches)
+}
+
+func TestFileTokenStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+	store := NewFileTokenStore(t.TempDir())
+	ctx := context.Background()
+
+	token, err := store.Load(ctx, "key")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+
+	createdAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	assert.Nil(t, store.Save(ctx, "key", &OAuthToken{AccessToken: "token", ExpiresIn: 300, RefreshToken: "refresh", CreatedAt: createdAt}))
+	token, err = store.Load(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+	assert.Equal(t, "refresh", token.RefreshToken)
+	assert.True(t, createdAt.Equal(token.CreatedAt))
+	assert.True(t, createdAt.Add(300*time.Second).Equal(token.ExpiresAt()))
+
+	other, err := store.Load(ctx, "other")
+	assert.Nil(t, err)
+	assert.Nil(t, other)
+}
+
+func TestFileTokenStoreConcurrentWriters(t *testing.T) {
+	t.Parallel()
+	store := NewFileTokenStore(t.TempDir())
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Nil(t, store.Save(ctx, "key", &OAuthToken{AccessToken: fmt.Sprintf("token-%d", i), ExpiresIn: 300, CreatedAt: time.Now()}))
+			token, err := store.Load(ctx, "key")
+			assert.Nil(t, err)
+			assert.NotNil(t, token)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestFileTokenStoreLock(t *testing.T) {
+	t.Parallel()
+	store := NewFileTokenStore(t.TempDir())
+	store.PollInterval = time.Millisecond
+	ctx := context.Background()
+
+	assertExclusive(t, 10, func() (func(), error) {
+		return store.Lock(ctx, "key")
+	})
+
+	unlock, err := store.Lock(ctx, "key")
+	assert.Nil(t, err)
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = store.Lock(timeout, "key")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	unlock()
+}
+
+// assertExclusive runs lockers concurrently and fails when two of them hold
+// the lock at once.
+func assertExclusive(t *testing.T, lockers int, lock func() (func(), error)) {
+	var holders, maxHolders int32
+	var wg sync.WaitGroup
+	for i := 0; i < lockers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lock()
+			if !assert.Nil(t, err) {
+				return
+			}
+			n := atomic.AddInt32(&holders, 1)
+			for {
+				max := atomic.LoadInt32(&maxHolders)
+				if n <= max || atomic.CompareAndSwapInt32(&maxHolders, max, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxHolders)
+}
+
+func TestFileTokenStoreStaleLock(t *testing.T) {
+	t.Parallel()
+	store := NewFileTokenStore(t.TempDir())
+	store.PollInterval = time.Millisecond
+	lock := store.path("key") + ".lock"
+	assert.Nil(t, os.WriteFile(lock, []byte("crashed"), 0o600))
+	old := time.Now().Add(-time.Hour)
+	assert.Nil(t, os.Chtimes(lock, old, old))
+
+	assertExclusive(t, 2, func() (func(), error) {
+		return store.Lock(context.Background(), "key")
+	})
+}
+
+func TestFileTokenStoreStaleLockTakeover(t *testing.T) {
+	t.Parallel()
+	store := NewFileTokenStore(t.TempDir())
+	store.PollInterval = time.Millisecond
+	store.StaleLockAge = time.Minute
+	assert.Nil(t, os.MkdirAll(store.Dir, 0o700))
+	lock := store.path("key") + ".lock"
+	ctx := context.Background()
+
+	// a holder that stalls past StaleLockAge, racing with a fresh locker
+	unlockStale, err := store.lockExclusive(ctx, lock)
+	assert.Nil(t, err)
+	old := time.Now().Add(-time.Hour)
+	assert.Nil(t, os.Chtimes(lock, old, old))
+	assertExclusive(t, 2, func() (func(), error) {
+		return store.lockExclusive(ctx, lock)
+	})
+
+	unlock, err := store.lockExclusive(ctx, lock)
+	assert.Nil(t, err)
+	old = time.Now().Add(-time.Hour)
+	assert.Nil(t, os.Chtimes(lock, old, old))
+	unlockNew, err := store.lockExclusive(ctx, lock)
+	assert.Nil(t, err)
+	// the holders whose locks were taken over must not remove the new lock
+	unlockStale()
+	unlock()
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = store.lockExclusive(timeout, lock)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	unlockNew()
+	unlock, err = store.lockExclusive(ctx, lock)
+	assert.Nil(t, err)
+	unlock()
+}
+
+func TestFileTokenStoreSharedBetweenAuthenticators(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	dir := t.TempDir()
+	base := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 300}`, atomic.AddInt32(&fetches, 1))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			authenticator := NewOAuthAuthenticator("test-client", "test-secret")
+			authenticator.OAuthURL = base.OAuthURL
+			authenticator.Store = NewFileTokenStore(dir)
+			token, err := authenticator.Authenticate()
+			assert.Nil(t, err)
+			assert.Equal(t, "token-1", token.AccessToken)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), fetches)
+}
@@ -0,0 +1,170 @@
+package vgs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type TransferCapture struct {
+	ID              string           `json:"id,omitempty"`
+	CreatedAt       time.Time        `json:"created_at,omitempty"`
+	Amount          int              `json:"amount,omitempty"`
+	State           string           `json:"state,omitempty"`
+	GatewayResponse *GatewayResponse `json:"gateway_response,omitempty"`
+}
+
+type TransferRefund struct {
+	ID              string           `json:"id,omitempty"`
+	CreatedAt       time.Time        `json:"created_at,omitempty"`
+	Amount          int              `json:"amount,omitempty"`
+	State           string           `json:"state,omitempty"`
+	GatewayResponse *GatewayResponse `json:"gateway_response,omitempty"`
+}
+
+type Transfer struct {
+	ID              string            `json:"id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at,omitempty"`
+	UpdatedAt       time.Time         `json:"updated_at,omitempty"`
+	Amount          int               `json:"amount,omitempty"`
+	AmountCaptured  int               `json:"amount_captured,omitempty"`
+	AmountRefunded  int               `json:"amount_refunded,omitempty"`
+	Fee             int               `json:"fee,omitempty"`
+	Currency        string            `json:"currency,omitempty"`
+	AutoCapture     bool              `json:"auto_capture,omitempty"`
+	Gateway         *GatewayInfo      `json:"gateway,omitempty"`
+	GatewayResponse *GatewayResponse  `json:"gateway_response,omitempty"`
+	Source          string            `json:"source,omitempty"`
+	Destination     string            `json:"destination,omitempty"`
+	State           string            `json:"state,omitempty"`
+	AvsResult       *AvsResult        `json:"avs_result,omitempty"`
+	SubAccountID    string            `json:"sub_account_id,omitempty"`
+	Captures        []TransferCapture `json:"captures,omitempty"`
+	Refunds         []TransferRefund  `json:"refunds,omitempty"`
+}
+
+type CreateTransferRequest struct {
+	// Amount in the smallest currency unit, e.g. cents.
+	Amount int `json:"amount"`
+	// ISO 4217 currency code. Defaults to the gateway default currency.
+	Currency string `json:"currency,omitempty"`
+	// Financial instrument id to charge.
+	Source string `json:"source"`
+	// Capture the funds immediately. When false the transfer is only
+	// authorized and must be captured or voided later.
+	AutoCapture    bool            `json:"auto_capture"`
+	GatewayOptions *GatewayOptions `json:"gateway_options,omitempty"`
+	SubAccountID   string          `json:"sub_account_id,omitempty"`
+}
+
+type CaptureTransferRequest struct {
+	// Amount to capture. Zero captures the full authorized amount.
+	Amount int `json:"amount,omitempty"`
+}
+
+type RefundTransferRequest struct {
+	// Amount to refund. Zero refunds the full captured amount.
+	Amount int `json:"amount,omitempty"`
+}
+
+type TransferResponse struct {
+	Data Transfer `json:"data,omitempty"`
+}
+
+type Transfers struct {
+	Response
+	Data []Transfer `json:"data,omitempty"`
+}
+
+func transferUri(id string, parts ...string) string {
+	uri := fmt.Sprintf("/transfers/%s", url.PathEscape(id))
+	for _, part := range parts {
+		uri += "/" + part
+	}
+	return uri
+}
+
+func (c *Client) GetTransfers(options ...RequestOption) (*Transfers, error) {
+	return c.GetTransfersContext(c.Ctx, options...)
+}
+
+func (c *Client) GetTransfersContext(ctx context.Context, options ...RequestOption) (*Transfers, error) {
+	transfers := &Transfers{}
+	_, err := c.GetContext(ctx, "/transfers", transfers, options...)
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (c *Client) GetTransfer(id string, options ...RequestOption) (*TransferResponse, error) {
+	return c.GetTransferContext(c.Ctx, id, options...)
+}
+
+func (c *Client) GetTransferContext(ctx context.Context, id string, options ...RequestOption) (*TransferResponse, error) {
+	resp := &TransferResponse{}
+	_, err := c.GetContext(ctx, transferUri(id), resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateTransfer(body *CreateTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	return c.CreateTransferContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreateTransferContext(ctx context.Context, body *CreateTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	resp := &TransferResponse{}
+	_, err := c.PostContext(ctx, "/transfers", body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CaptureTransfer(id string, body *CaptureTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	return c.CaptureTransferContext(c.Ctx, id, body, options...)
+}
+
+func (c *Client) CaptureTransferContext(ctx context.Context, id string, body *CaptureTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	if body == nil {
+		body = &CaptureTransferRequest{}
+	}
+	resp := &TransferResponse{}
+	_, err := c.PostContext(ctx, transferUri(id, "capture"), body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) VoidTransfer(id string, options ...RequestOption) (*TransferResponse, error) {
+	return c.VoidTransferContext(c.Ctx, id, options...)
+}
+
+func (c *Client) VoidTransferContext(ctx context.Context, id string, options ...RequestOption) (*TransferResponse, error) {
+	resp := &TransferResponse{}
+	_, err := c.PostContext(ctx, transferUri(id, "void"), nil, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) RefundTransfer(id string, body *RefundTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	return c.RefundTransferContext(c.Ctx, id, body, options...)
+}
+
+func (c *Client) RefundTransferContext(ctx context.Context, id string, body *RefundTransferRequest, options ...RequestOption) (*TransferResponse, error) {
+	if body == nil {
+		body = &RefundTransferRequest{}
+	}
+	resp := &TransferResponse{}
+	_, err := c.PostContext(ctx, transferUri(id, "refunds"), body, resp, options...)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,83 @@
+package vgs
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/transfers", r.URL.Path)
+		var req CreateTransferRequest
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, 1000, req.Amount)
+		assert.Equal(t, "FI123", req.Source)
+		assert.False(t, req.AutoCapture)
+		w.Write([]byte(`{"data": {"id": "XF123", "amount": 1000, "state": "authorized", "avs_result": {"code": "Y"}}}`))
+	})
+	transfer, err := c.CreateTransfer(&CreateTransferRequest{
+		Amount:   1000,
+		Currency: "USD",
+		Source:   "FI123",
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, transfer)
+	assert.Equal(t, "XF123", transfer.Data.ID)
+	assert.Equal(t, "authorized", transfer.Data.State)
+	assert.Equal(t, "Y", transfer.Data.AvsResult.Code)
+}
+
+func TestCreateTransferBadRequest(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusBadRequest, `{"errors": [{"code": "invalid", "detail": "dummy error"}]}`, nil))
+	transfer, err := c.CreateTransfer(&CreateTransferRequest{Amount: 1000, Source: "FI123"})
+	assert.NotNil(t, err)
+	assert.Nil(t, transfer)
+	assert.ErrorContains(t, err, "dummy")
+}
+
+func TestCaptureTransfer(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodPost, "/transfers/XF123/capture", http.StatusOK, `{"data": {"id": "XF123", "amount_captured": 500}}`))
+	transfer, err := c.CaptureTransfer("XF123", &CaptureTransferRequest{Amount: 500})
+	assert.Nil(t, err)
+	assert.Equal(t, 500, transfer.Data.AmountCaptured)
+}
+
+func TestVoidTransfer(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodPost, "/transfers/XF123/void", http.StatusOK, `{"data": {"id": "XF123", "state": "voided"}}`))
+	transfer, err := c.VoidTransfer("XF123")
+	assert.Nil(t, err)
+	assert.Equal(t, "voided", transfer.Data.State)
+}
+
+func TestRefundTransfer(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodPost, "/transfers/XF123/refunds", http.StatusOK, `{"data": {"id": "XF123", "amount_refunded": 1000, "refunds": [{"id": "RF1", "amount": 1000}]}}`))
+	transfer, err := c.RefundTransfer("XF123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, transfer.Data.AmountRefunded)
+	assert.Len(t, transfer.Data.Refunds, 1)
+}
+
+func TestGetTransfer(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/transfers/XF123", http.StatusOK, `{"data": {"id": "XF123"}}`))
+	transfer, err := c.GetTransfer("XF123")
+	assert.Nil(t, err)
+	assert.Equal(t, "XF123", transfer.Data.ID)
+}
+
+func TestGetTransfers(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newExpectHandler(t, http.MethodGet, "/transfers", http.StatusOK, `{"meta": {}, "links": {}, "data": [{"id": "XF123"}, {"id": "XF456"}]}`))
+	transfers, err := c.GetTransfers()
+	assert.Nil(t, err)
+	assert.Len(t, transfers.Data, 2)
+}
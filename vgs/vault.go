@@ -0,0 +1,223 @@
+package vgs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+	ContentTypeXML  = "application/xml"
+)
+
+// VaultClient sends payloads through the inbound routes of the vault
+// reverse proxy, which redacts them before they reach the upstream.
+type VaultClient struct {
+	client *Client
+	// Content type of request bodies unless set with WithContentType,
+	// defaults to ContentTypeJSON.
+	ContentType string
+}
+
+func NewVaultClient(options *Options) (*VaultClient, error) {
+	return NewVaultClientWithContext(context.Background(), options)
+}
+
+// NewVaultClientWithContext returns a VaultClient for the inbound routes of
+// options.VaultId. Inbound routes do not need API credentials.
+func NewVaultClientWithContext(ctx context.Context, options *Options) (*VaultClient, error) {
+	if options.VaultId == "" {
+		return nil, errors.New("vault id is required")
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.Environment == "" {
+		options.Environment = Sandbox
+	}
+	return &VaultClient{client: &Client{Options: options, Ctx: ctx}, ContentType: ContentTypeJSON}, nil
+}
+
+// VaultClient returns a VaultClient sharing the options of the client.
+func (c *Client) VaultClient() *VaultClient {
+	return &VaultClient{client: c, ContentType: ContentTypeJSON}
+}
+
+func (v *VaultClient) Get(path string, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(v.client.Ctx, http.MethodGet, path, nil, out, options...)
+}
+
+func (v *VaultClient) GetContext(ctx context.Context, path string, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(ctx, http.MethodGet, path, nil, out, options...)
+}
+
+func (v *VaultClient) Post(path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(v.client.Ctx, http.MethodPost, path, payload, out, options...)
+}
+
+func (v *VaultClient) PostContext(ctx context.Context, path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(ctx, http.MethodPost, path, payload, out, options...)
+}
+
+func (v *VaultClient) Put(path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(v.client.Ctx, http.MethodPut, path, payload, out, options...)
+}
+
+func (v *VaultClient) PutContext(ctx context.Context, path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(ctx, http.MethodPut, path, payload, out, options...)
+}
+
+func (v *VaultClient) Send(method, path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	return v.SendContext(v.client.Ctx, method, path, payload, out, options...)
+}
+
+// SendContext sends payload to path on the vault url. A []byte, string or
+// url.Values payload is sent as is, anything else is encoded according to
+// the content type. The response body is decoded into out according to its
+// content type, or copied when out is an io.Writer, *[]byte or *string.
+func (v *VaultClient) SendContext(ctx context.Context, method, path string, payload, out interface{}, options ...RequestOption) (*Response, error) {
+	request := NewJsonRequest(method, path, payload, options...)
+	request.unauthenticated = true
+	ctx, cancel := request.context(ctx)
+	defer cancel()
+	req, err := v.NewRequestContext(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.do(ctx, request, req, nil)
+	if err == nil {
+		err = decodeBody(resp, out)
+	}
+	if request.response != nil {
+		*request.response = resp
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// NewRequestContext builds a request to the vault url.
+func (v *VaultClient) NewRequestContext(ctx context.Context, request *Request) (*http.Request, error) {
+	baseUrl := request.BaseURL
+	if baseUrl == nil {
+		vaultUrl, err := v.client.Options.GetVaultUrl()
+		if err != nil {
+			return nil, err
+		}
+		baseUrl = vaultUrl
+	}
+	fullUrl, err := baseUrl.Parse(request.Uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Values) > 0 {
+		query := fullUrl.Query()
+		for key, values := range request.Values {
+			query[key] = append(query[key], values...)
+		}
+		fullUrl.RawQuery = query.Encode()
+	}
+	contentType := request.Headers.Get("Content-Type")
+	if contentType == "" {
+		contentType = v.ContentType
+	}
+	body, err := encodeBody(contentType, request.Body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, fullUrl.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, values := range request.Headers {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return t
+}
+
+func isXML(contentType string) bool {
+	t := mediaType(contentType)
+	return t == ContentTypeXML || t == "text/xml"
+}
+
+func encodeBody(contentType string, body interface{}) ([]byte, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	case url.Values:
+		return []byte(b.Encode()), nil
+	}
+	switch {
+	case isXML(contentType):
+		return xml.Marshal(body)
+	case mediaType(contentType) == ContentTypeForm:
+		return nil, fmt.Errorf("form body must be url.Values, got %T", body)
+	default:
+		return json.Marshal(body)
+	}
+}
+
+func decodeBody(response *Response, out interface{}) error {
+	if out == nil {
+		return nil
+	}
+	switch o := out.(type) {
+	case io.Writer:
+		_, err := o.Write(response.RawBody)
+		return err
+	case *[]byte:
+		*o = response.RawBody
+		return nil
+	case *string:
+		*o = string(response.RawBody)
+		return nil
+	}
+	if len(response.RawBody) == 0 {
+		return nil
+	}
+	contentType := response.Header.Get("Content-Type")
+	switch {
+	case isXML(contentType):
+		return xml.Unmarshal(response.RawBody, out)
+	case mediaType(contentType) == ContentTypeForm:
+		values, ok := out.(*url.Values)
+		if !ok {
+			return fmt.Errorf("form response must be decoded into *url.Values, got %T", out)
+		}
+		parsed, err := url.ParseQuery(string(response.RawBody))
+		*values = parsed
+		return err
+	default:
+		return json.Unmarshal(response.RawBody, out)
+	}
+}
@@ -0,0 +1,129 @@
+package vgs
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockVaultClient(handler http.HandlerFunc) *VaultClient {
+	v, _ := NewVaultClient(&Options{VaultId: "tntabc", HTTPClient: &mockHTTPClient{mockHandler: handler}})
+	return v
+}
+
+func TestVaultClientPostJSON(t *testing.T) {
+	t.Parallel()
+	v := newMockVaultClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "tntabc.sandbox.verygoodproxy.com", r.URL.Host)
+		assert.Equal(t, "/post", r.URL.Path)
+		assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"card_number": "4111111111111111"}`, string(body))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set(VGSRequestId, "req-1")
+		w.Write([]byte(`{"card_number": "tok_sandbox_1"}`))
+	})
+	var out map[string]string
+	resp, err := v.Post("/post", map[string]string{"card_number": "4111111111111111"}, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "req-1", resp.VGSRequestId)
+	assert.Equal(t, "tok_sandbox_1", out["card_number"])
+}
+
+func TestVaultClientPostForm(t *testing.T) {
+	t.Parallel()
+	v := newMockVaultClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ContentTypeForm, r.Header.Get("Content-Type"))
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "4111111111111111", r.PostForm.Get("card"))
+		w.Header().Set("Content-Type", ContentTypeForm)
+		w.Write([]byte("card=tok_1"))
+	})
+	var out url.Values
+	_, err := v.Post("/form", url.Values{"card": {"4111111111111111"}}, &out, WithContentType(ContentTypeForm))
+	assert.Nil(t, err)
+	assert.Equal(t, "tok_1", out.Get("card"))
+
+	_, err = v.Post("/form", map[string]string{"card": "4111111111111111"}, nil, WithContentType(ContentTypeForm))
+	assert.NotNil(t, err)
+}
+
+func TestVaultClientPostXML(t *testing.T) {
+	t.Parallel()
+	type card struct {
+		Number string `xml:"number"`
+	}
+	v := newMockVaultClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ContentTypeXML, r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `<card><number>4111111111111111</number></card>`, string(body))
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(`<card><number>tok_1</number></card>`))
+	})
+	v.ContentType = ContentTypeXML
+	var out card
+	_, err := v.Post("/xml", card{Number: "4111111111111111"}, &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "tok_1", out.Number)
+}
+
+func TestVaultClientError(t *testing.T) {
+	t.Parallel()
+	v := newMockVaultClient(newMockHandler(http.StatusBadGateway, "upstream failed", map[string]string{VGSRequestId: "req-2"}))
+	var resp *Response
+	_, err := v.Get("/status", nil, WithResponse(&resp))
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, "req-2", resp.VGSRequestId)
+	assert.Equal(t, "upstream failed", string(resp.RawBody))
+}
+
+func TestClientVaultClient(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "tntabc.sandbox.verygoodproxy.com", r.URL.Host)
+		w.Write([]byte("raw"))
+	})
+	c.Options.VaultId = "tntabc"
+	var out string
+	_, err := c.VaultClient().Put("/raw", "payload", &out)
+	assert.Nil(t, err)
+	assert.Equal(t, "raw", out)
+
+	_, err = NewVaultClient(&Options{})
+	assert.NotNil(t, err)
+}
+
+func TestClientVaultClientSkipsAuthentication(t *testing.T) {
+	t.Parallel()
+	var fetches int32
+	authenticator := newTokenServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write([]byte(`{"access_token": "token", "expires_in": 300}`))
+	})
+	var calls int32
+	c, err := NewClient(&Options{
+		ClientID:      "test-client",
+		ClientSecret:  "test-secret",
+		VaultId:       "tntabc",
+		RouteId:       "test-route",
+		Authenticator: authenticator,
+		HTTPClient: &mockHTTPClient{func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			assert.Empty(t, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_token", "error_description": "Token expired"}`))
+		}},
+	})
+	assert.Nil(t, err)
+
+	_, err = c.VaultClient().Post("/post", map[string]string{"card_number": "4111111111111111"}, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&fetches))
+}
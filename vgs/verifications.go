@@ -1,6 +1,10 @@
 package vgs
 
-import "time"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
 
 type GatewayOptions struct {
 	Currency        string          `json:"currency,omitempty"`
@@ -13,13 +17,32 @@ type VerificationsRequest struct {
 }
 
 type GatewayInfo struct {
-	Type            string      `json:"type,omitempty"`
-	ID              string      `json:"id,omitempty"`
-	DefaultCurrency string      `json:"default_currency,omitempty"`
-	DefaultGateway  bool        `json:"default_gateway,omitempty"`
-	Config          interface{} `json:"config,omitempty"`
-	CreatedAt       time.Time   `json:"created_at,omitempty"`
-	UpdatedAt       time.Time   `json:"updated_at,omitempty"`
+	Type            string `json:"type,omitempty"`
+	ID              string `json:"id,omitempty"`
+	DefaultCurrency string `json:"default_currency,omitempty"`
+	DefaultGateway  bool   `json:"default_gateway,omitempty"`
+	// Decoded into a typed config based on Type, or RawGatewayConfig for
+	// unknown types.
+	Config    GatewayConfig `json:"config,omitempty"`
+	CreatedAt time.Time     `json:"created_at,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at,omitempty"`
+}
+
+func (g *GatewayInfo) UnmarshalJSON(data []byte) error {
+	type gatewayInfo GatewayInfo
+	aux := &struct {
+		*gatewayInfo
+		Config json.RawMessage `json:"config"`
+	}{gatewayInfo: (*gatewayInfo)(g)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	config, err := DecodeGatewayConfig(g.Type, aux.Config)
+	if err != nil {
+		return err
+	}
+	g.Config = config
+	return nil
 }
 
 type GatewayResponse struct {
@@ -58,9 +81,13 @@ type VerficiationsResponse struct {
 	Data Verficiation `json:"data,omitempty"`
 }
 
-func (c *Client) CreateVerifications(body *VerificationsRequest) (*VerficiationsResponse, error) {
+func (c *Client) CreateVerifications(body *VerificationsRequest, options ...RequestOption) (*VerficiationsResponse, error) {
+	return c.CreateVerificationsContext(c.Ctx, body, options...)
+}
+
+func (c *Client) CreateVerificationsContext(ctx context.Context, body *VerificationsRequest, options ...RequestOption) (*VerficiationsResponse, error) {
 	resp := &VerficiationsResponse{}
-	_, err := c.Post("/verfications", body, resp)
+	_, err := c.PostContext(ctx, "/verfications", body, resp, options...)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package vgs
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -18,3 +19,17 @@ func TestCreateVerifications(t *testing.T) {
 	assert.NotNil(t, verification)
 	assert.NotNil(t, verification.Data)
 }
+
+func TestVerificationGatewayConfigRedacted(t *testing.T) {
+	t.Parallel()
+	c := NewMockClientWithHandler(newMockHandler(http.StatusOK, `{"data": {"gateway": {
+		"id": "GW1", "type": "stripe", "config": {"secret_key": "secret-stripe"}}}}`, nil))
+	verification, err := c.CreateVerifications(&VerificationsRequest{Card: &Card{}})
+	assert.Nil(t, err)
+	assert.Equal(t, &StripeConfig{SecretKey: "secret-stripe"}, verification.Data.Gateway.Config)
+	assert.NotContains(t, fmt.Sprintf("%+v", verification.Data.Gateway), "secret-stripe")
+
+	raw := RawGatewayConfig{Type: "unknown_psp", Raw: []byte(`{"api_key":"secret-raw"}`)}
+	assert.Equal(t, `{"api_key":"[REDACTED]"}`, string(raw.redacted().(redactedRawGatewayConfig).Raw))
+	assert.Equal(t, `{"api_key":"secret-raw"}`, string(raw.Raw))
+}